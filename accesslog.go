@@ -5,6 +5,7 @@ package accesslog
 
 import (
 	"context"
+	"log/slog"
 	"time"
 
 	"github.com/rs/zerolog"
@@ -16,6 +17,12 @@ type LogEntry interface {
 	Add(func(e *zerolog.Event))
 }
 
+// AttrAdder is implemented by LogEntry adding fields of slog.Attr without depending on zerolog.
+// Entries not implementing it get attrs by LogEntry.Add.
+type AttrAdder interface {
+	AddAttrs(attrs ...slog.Attr)
+}
+
 // LogEntryCtxKey is the context key for LogEntry.
 var LogEntryCtxKey = struct{}{}
 
@@ -0,0 +1,79 @@
+package accesslog
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/rs/zerolog"
+)
+
+// AddAttrs adds attrs to the LogEntry in ctx, so that handlers can add fields without depending on zerolog.
+// It does nothing if ctx has no LogEntry.
+func AddAttrs(ctx context.Context, attrs ...slog.Attr) {
+	if le := GetLogEntry(ctx); le != nil {
+		addAttrs(le, attrs)
+	}
+}
+
+// addAttrs adds attrs to le by AttrAdder if implemented, or by LogEntry.Add.
+func addAttrs(le LogEntry, attrs []slog.Attr) {
+	if a, ok := le.(AttrAdder); ok {
+		a.AddAttrs(attrs...)
+		return
+	}
+	le.Add(attrsFunc(attrs))
+}
+
+// attrsFunc returns the function adding attrs to log event, for AttrAdder to be implemented by LogEntry.Add.
+func attrsFunc(attrs []slog.Attr) func(e *zerolog.Event) {
+	return func(e *zerolog.Event) {
+		for _, a := range attrs {
+			writeAttr(e, a)
+		}
+	}
+}
+
+// writeAttr writes a into e. Groups are written as objects, or inlined if their keys are empty like slog.
+func writeAttr(e *zerolog.Event, a slog.Attr) {
+	v := a.Value.Resolve()
+	if v.Kind() == slog.KindGroup {
+		if a.Key == "" {
+			for _, ga := range v.Group() {
+				writeAttr(e, ga)
+			}
+			return
+		}
+		d := zerolog.Dict()
+		for _, ga := range v.Group() {
+			writeAttr(d, ga)
+		}
+		e.Dict(a.Key, d)
+		return
+	}
+	if a.Key == "" {
+		return
+	}
+
+	switch v.Kind() {
+	case slog.KindString:
+		e.Str(a.Key, v.String())
+	case slog.KindInt64:
+		e.Int64(a.Key, v.Int64())
+	case slog.KindUint64:
+		e.Uint64(a.Key, v.Uint64())
+	case slog.KindFloat64:
+		e.Float64(a.Key, v.Float64())
+	case slog.KindBool:
+		e.Bool(a.Key, v.Bool())
+	case slog.KindDuration:
+		e.Dur(a.Key, v.Duration())
+	case slog.KindTime:
+		e.Time(a.Key, v.Time())
+	default:
+		if err, ok := v.Any().(error); ok {
+			e.Str(a.Key, err.Error())
+			return
+		}
+		e.Interface(a.Key, v.Any())
+	}
+}
@@ -0,0 +1,88 @@
+package accesslog
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+func Test_writeAttr(t *testing.T) {
+	tests := []struct {
+		name string
+		attr slog.Attr
+		want string
+	}{
+		{name: "string", attr: slog.String("user", "u1"), want: `{"user":"u1"}`},
+		{name: "int", attr: slog.Int("n", 3), want: `{"n":3}`},
+		{name: "bool", attr: slog.Bool("ok", true), want: `{"ok":true}`},
+		{name: "duration", attr: slog.Duration("d", 1500*time.Microsecond), want: `{"d":1.5}`},
+		{name: "error", attr: slog.Any("err", errors.New("boom")), want: `{"err":"boom"}`},
+		{name: "group", attr: slog.Group("g", slog.String("a", "b")), want: `{"g":{"a":"b"}}`},
+		{name: "inlined group", attr: slog.Group("", slog.String("a", "b")), want: `{"a":"b"}`},
+		{name: "empty key", attr: slog.String("", "x"), want: `{}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			l := zerolog.New(&buf)
+			e := l.Log()
+			writeAttr(e, tt.attr)
+			e.Send()
+			if got := buf.String(); got != tt.want+"\n" {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAddAttrs(t *testing.T) {
+	var buf bytes.Buffer
+	l := zerolog.New(&buf)
+	le := &DefaultGRPCClientLogEntry{}
+	ctx := SetLogEntry(context.Background(), le)
+
+	AddAttrs(ctx, slog.String("user", "u1"))
+	AddAttrs(context.Background(), slog.String("ignored", "x"))
+
+	e := l.Log()
+	for _, f := range le.add {
+		f(e)
+	}
+	e.Send()
+	if got, want := buf.String(), `{"user":"u1"}`+"\n"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+// addOnlyEntry is a LogEntry not implementing AttrAdder.
+type addOnlyEntry struct {
+	add []func(e *zerolog.Event)
+}
+
+func (le *addOnlyEntry) Write(t time.Time) {}
+
+func (le *addOnlyEntry) Add(f func(e *zerolog.Event)) {
+	le.add = append(le.add, f)
+}
+
+func TestAddAttrs_withoutAttrAdder(t *testing.T) {
+	var buf bytes.Buffer
+	l := zerolog.New(&buf)
+	le := &addOnlyEntry{}
+
+	AddAttrs(SetLogEntry(context.Background(), le), slog.String("user", "u1"))
+
+	e := l.Log()
+	for _, f := range le.add {
+		f(e)
+	}
+	e.Send()
+	if got, want := buf.String(), `{"user":"u1"}`+"\n"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,184 @@
+// Command accesslog-schema prints the schema of access logs produced by the default formatters configured by flags.
+//
+//	accesslog-schema -format athena -table access_log -location s3://bucket/accesslog/ -http-headers x-request-id:rid -grpc-metadata user-agent
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/daangn/accesslog"
+)
+
+func main() {
+	var (
+		format   = flag.String("format", "athena", "output format: athena, bigquery or jsonschema")
+		protocol = flag.String("protocol", "all", "protocol of logs: http, grpc or all")
+		table    = flag.String("table", "access_log", "table name of athena")
+		location = flag.String("location", "s3://bucket/accesslog/", "S3 location of logs for athena")
+		storage  = flag.String("storage", "json", "storage format of logs for athena: json or parquet")
+		pfield   = flag.String("partition-field", "", "field passed to S3PartitionField of the s3 writer, if not the protocol field")
+
+		headers      = flag.String("http-headers", "", "comma separated headers passed to WithHeaders")
+		withMethod   = flag.Bool("http-method", false, "WithMethod")
+		withBytesIn  = flag.Bool("http-bytes-in", false, "WithBytesIn")
+		withBytesOut = flag.Bool("http-bytes-out", false, "WithBytesOut")
+		withProto    = flag.Bool("http-proto", false, "WithProto")
+		withHost     = flag.Bool("http-host", false, "WithHost")
+		withScheme   = flag.Bool("http-scheme", false, "WithScheme")
+		withClientIP = flag.Bool("http-client-ip", false, "WithClientIP")
+		withRoute    = flag.Bool("http-route", false, "WithRoutePattern")
+		withReqBody  = flag.Bool("http-request-body", false, "WithRequestBody")
+		withResBody  = flag.Bool("http-response-body", false, "WithResponseBody")
+
+		md                = flag.String("grpc-metadata", "", "comma separated metadata passed to WithMetadata")
+		withPeer          = flag.Bool("grpc-peer", false, "WithPeer")
+		withRequest       = flag.Bool("grpc-request", false, "WithRequest")
+		withResponse      = flag.Bool("grpc-response", false, "WithResponse")
+		withStatusMessage = flag.Bool("grpc-status-message", false, "WithStatusMessage")
+		withErrorDetails  = flag.Bool("grpc-error-details", false, "WithErrorDetails")
+		withErrorOrigin   = flag.Bool("grpc-error-origin", false, "WithErrorOrigin")
+
+		sampled       = flag.Bool("sampled", false, "WithSampler")
+		schemaVersion = flag.Int("schema-version", 1, "WithSchemaVersion: 1 for the legacy layout or 2")
+		unixMilli     = flag.Bool("time-unix-milli", false, "WithTimeFormat(TimeUnixMilli)")
+		naming        = flag.String("naming", "", "WithFieldNaming: ecs, otel or gcp")
+		withTrace     = flag.Bool("trace", false, "WithTrace")
+		requestID     = flag.Bool("request-id", false, "WithRequestID")
+	)
+	flag.Parse()
+
+	var common []accesslog.Option
+	if *sampled {
+		common = append(common, accesslog.WithSampler(accesslog.RateSampler(1)))
+	}
+	common = append(common, accesslog.WithSchemaVersion(accesslog.SchemaVersion(*schemaVersion)))
+	if *unixMilli {
+		common = append(common, accesslog.WithTimeFormat(accesslog.TimeUnixMilli))
+	}
+	if *requestID {
+		common = append(common, accesslog.WithRequestID(""))
+	}
+	if *withTrace {
+		common = append(common, accesslog.WithTrace())
+	}
+	switch *naming {
+	case "":
+	case "ecs":
+		common = append(common, accesslog.WithFieldNaming(accesslog.ECSNaming()))
+	case "otel":
+		common = append(common, accesslog.WithFieldNaming(accesslog.OTelNaming()))
+	case "gcp":
+		common = append(common, accesslog.WithFieldNaming(accesslog.GCPNaming()))
+	default:
+		fail(fmt.Errorf("unknown naming: %s", *naming))
+	}
+
+	var hopts []accesslog.HTTPOption
+	if *headers != "" {
+		hopts = append(hopts, accesslog.WithHeaders(split(*headers)...))
+	}
+	for _, o := range []struct {
+		on  bool
+		opt accesslog.HTTPOption
+	}{
+		{*withMethod, accesslog.WithMethod()},
+		{*withBytesIn, accesslog.WithBytesIn()},
+		{*withBytesOut, accesslog.WithBytesOut()},
+		{*withProto, accesslog.WithProto()},
+		{*withHost, accesslog.WithHost()},
+		{*withScheme, accesslog.WithScheme()},
+		{*withClientIP, accesslog.WithClientIP()},
+		{*withRoute, accesslog.WithRoutePattern(nil)},
+		{*withReqBody, accesslog.WithRequestBody()},
+		{*withResBody, accesslog.WithResponseBody()},
+	} {
+		if o.on {
+			hopts = append(hopts, o.opt)
+		}
+	}
+
+	var gopts []accesslog.GRPCOption
+	if *md != "" {
+		gopts = append(gopts, accesslog.WithMetadata(split(*md)...))
+	}
+	for _, o := range []struct {
+		on  bool
+		opt accesslog.GRPCOption
+	}{
+		{*withPeer, accesslog.WithPeer()},
+		{*withRequest, accesslog.WithRequest()},
+		{*withResponse, accesslog.WithResponse()},
+		{*withStatusMessage, accesslog.WithStatusMessage()},
+		{*withErrorDetails, accesslog.WithErrorDetails()},
+		{*withErrorOrigin, accesslog.WithErrorOrigin()},
+	} {
+		if o.on {
+			gopts = append(gopts, o.opt)
+		}
+	}
+	for _, o := range common {
+		hopts = append(hopts, o)
+		gopts = append(gopts, o)
+	}
+
+	var ss []*accesslog.Schema
+	if *protocol == "all" || *protocol == "http" {
+		ss = append(ss, accesslog.HTTPSchema(accesslog.NewDefaultHTTPLogFormatter(hopts...)))
+	}
+	if *protocol == "all" || *protocol == "grpc" {
+		ss = append(ss, accesslog.GRPCSchema(accesslog.NewDefaultGRPCLogFormatter(gopts...)))
+	}
+	if len(ss) == 0 {
+		fail(fmt.Errorf("unknown protocol: %s", *protocol))
+	}
+	s := accesslog.MergeSchemas(ss...)
+	if *pfield != "" {
+		s = s.PartitionBy(*pfield)
+	}
+
+	switch *format {
+	case "athena":
+		var sf accesslog.StorageFormat
+		switch *storage {
+		case "json":
+			sf = accesslog.JSONStorage
+		case "parquet":
+			sf = accesslog.ParquetStorage
+		default:
+			fail(fmt.Errorf("unknown storage format: %s", *storage))
+		}
+		fmt.Print(s.AthenaDDL(*table, *location, sf))
+	case "bigquery":
+		b, err := s.BigQuery()
+		if err != nil {
+			fail(err)
+		}
+		fmt.Println(string(b))
+	case "jsonschema":
+		b, err := s.JSONSchema()
+		if err != nil {
+			fail(err)
+		}
+		fmt.Println(string(b))
+	default:
+		fail(fmt.Errorf("unknown format: %s", *format))
+	}
+}
+
+func split(s string) []string {
+	var ss []string
+	for _, e := range strings.Split(s, ",") {
+		if e = strings.TrimSpace(e); e != "" {
+			ss = append(ss, e)
+		}
+	}
+	return ss
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
+}
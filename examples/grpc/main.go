@@ -25,6 +25,11 @@ func (s *server) SayHello(ctx context.Context, in *pb.HelloRequest) (*pb.HelloRe
 }
 
 func main() {
+	logger := accesslog.NewGRPCLogger(os.Stdout, accesslog.NewDefaultGRPCLogFormatter(
+		accesslog.WithRequest(),
+		accesslog.WithResponse(),
+	))
+
 	srv := grpc.NewServer(
 		grpc.KeepaliveParams(keepalive.ServerParameters{
 			MaxConnectionIdle:     15 * time.Second,
@@ -37,12 +42,8 @@ func main() {
 			MinTime:             5 * time.Second,
 			PermitWithoutStream: true,
 		}),
-		grpc.UnaryInterceptor(
-			middleware.UnaryServerInterceptor(accesslog.NewGRPCLogger(os.Stdout, accesslog.NewDefaultGRPCLogFormatter(
-				accesslog.WithRequest(),
-				accesslog.WithResponse(),
-			))),
-		),
+		grpc.UnaryInterceptor(middleware.UnaryServerInterceptor(logger)),
+		grpc.StreamInterceptor(middleware.StreamServerInterceptor(logger)),
 	)
 	reflection.Register(srv)
 	pb.RegisterGreeterServer(srv, &server{})
@@ -4,16 +4,17 @@ import (
 	"context"
 	"encoding/json"
 	"io"
+	"log/slog"
 	"os"
 	"time"
 
-	"github.com/golang/protobuf/jsonpb"
-	"github.com/golang/protobuf/proto"
 	"github.com/rs/zerolog"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/peer"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
 )
 
 // DefaultGRPCLogger is default gRPC Logger.
@@ -39,17 +40,50 @@ func (l *GRPCLogger) NewLogEntry(ctx context.Context, req interface{}, res *inte
 	return l.f.NewLogEntry(l.l, ctx, req, res, info, err)
 }
 
+// NewStreamLogEntry returns a New LogEntry for a stream.
+// It returns the entry writing nothing if the formatter of the logger doesn't implement GRPCStreamLogFormatter.
+func (l *GRPCLogger) NewStreamLogEntry(ctx context.Context, info *grpc.StreamServerInfo, stats *GRPCStreamStats, err *error) LogEntry {
+	if f, ok := l.f.(GRPCStreamLogFormatter); ok {
+		return f.NewStreamLogEntry(l.l, ctx, info, stats, err)
+	}
+	return nopLogEntry{}
+}
+
 // GRPCLogFormatter is the interface for NewLogEntry method.
 type GRPCLogFormatter interface {
 	NewLogEntry(l *zerolog.Logger, ctx context.Context, req interface{}, res *interface{}, info *grpc.UnaryServerInfo, err *error) LogEntry
 }
 
 type grpcConfig struct {
+	commonConfig
+
 	ignoredMethods map[string]struct{}
 	metadata       map[string]string
 	withRequest    bool
 	withResponse   bool
 	withPeer       bool
+
+	withStatusMessage bool
+	withErrorDetails  bool
+	withErrorOrigin   bool
+
+	protoJSON     protojson.MarshalOptions
+	marshaler     MessageMarshaler
+	messageLimit  int
+	requestMasks  map[string]*FieldMask
+	responseMasks map[string]*FieldMask
+
+	rules  *grpcRules
+	ignore bool
+}
+
+// newGRPCConfig returns a new grpcConfig applied opts.
+func newGRPCConfig(opts ...grpcOption) *grpcConfig {
+	cfg := new(grpcConfig)
+	for _, opt := range opts {
+		opt.applyGRPC(cfg)
+	}
+	return cfg
 }
 
 // DefaultGRPCLogFormatter is default GRPCLogFormatter.
@@ -59,25 +93,22 @@ type DefaultGRPCLogFormatter struct {
 
 // NewDefaultGRPCLogFormatter returns a new DefaultGRPCLogFormatter.
 func NewDefaultGRPCLogFormatter(opts ...grpcOption) *DefaultGRPCLogFormatter {
-	cfg := new(grpcConfig)
-	for _, fn := range opts {
-		fn(cfg)
-	}
-
-	return &DefaultGRPCLogFormatter{cfg: cfg}
+	return &DefaultGRPCLogFormatter{cfg: newGRPCConfig(opts...)}
 }
 
 // NewLogEntry returns a New LogEntry formatted in DefaultGRPCLogFormatter.
 func (f *DefaultGRPCLogFormatter) NewLogEntry(l *zerolog.Logger, ctx context.Context, req interface{}, res *interface{}, info *grpc.UnaryServerInfo, err *error) LogEntry {
 	return &DefaultGRPCLogEntry{
 		l:    l,
-		cfg:  f.cfg,
+		cfg:  f.cfg.forMethod(info.FullMethod),
 		ctx:  ctx,
 		req:  req,
 		res:  res,
 		info: info,
 		add:  []func(e *zerolog.Event){},
 		err:  err,
+
+		requestID: f.cfg.grpcRequestID(ctx),
 	}
 }
 
@@ -91,6 +122,10 @@ type DefaultGRPCLogEntry struct {
 	info *grpc.UnaryServerInfo
 	err  *error
 	add  []func(e *zerolog.Event)
+	logs appLogs
+
+	requestID string
+	panic     *panicInfo
 }
 
 // Add adds function for adding fields to log event.
@@ -102,61 +137,111 @@ func (le *DefaultGRPCLogEntry) Add(f func(e *zerolog.Event)) {
 	le.add = append(le.add, f)
 }
 
+// AddAttrs implements AttrAdder.
+func (le *DefaultGRPCLogEntry) AddAttrs(attrs ...slog.Attr) {
+	le.Add(attrsFunc(attrs))
+}
+
+// appendLog implements appLogger.
+func (le *DefaultGRPCLogEntry) appendLog(key string, l appLog) {
+	le.logs.append(key, l, le.Add)
+}
+
+// RequestID returns the request ID if WithRequestID is set.
+func (le *DefaultGRPCLogEntry) RequestID() string {
+	return le.requestID
+}
+
+// RecordPanic implements PanicRecorder.
+func (le *DefaultGRPCLogEntry) RecordPanic(v interface{}, stack []byte) {
+	le.panic = newPanicInfo(v, stack)
+}
+
+// code returns the code of the error, or codes.Internal if a panic is recovered.
+func (le *DefaultGRPCLogEntry) code() codes.Code {
+	if le.panic != nil {
+		return codes.Internal
+	}
+	return status.Code(*le.err)
+}
+
 // Write writes a log.
 func (le *DefaultGRPCLogEntry) Write(t time.Time) {
-	if _, ok := le.cfg.ignoredMethods[le.info.FullMethod]; ok {
+	if le.cfg.ignored(le.info.FullMethod) {
 		return
 	}
 
-	e := le.l.Log().
-		Str("protocol", "grpc").
-		Str("method", le.info.FullMethod).
-		Str("status", status.Code(*le.err).String()).
-		Str("time", t.UTC().Format(time.RFC3339Nano)).
-		Dur("elapsed(ms)", time.Since(t))
-
-	if wm := le.cfg.metadata; len(wm) != 0 {
-		if md, ok := metadata.FromIncomingContext(le.ctx); ok {
-			for m, a := range wm {
-				if ms := md.Get(m); len(ms) != 0 {
-					b, err := json.Marshal(ms)
-					if err == nil {
-						n := m
-						if a != "" {
-							n = a
-						}
-						e.Str(n, string(b))
-					}
-				}
-			}
-		}
+	md, _ := metadata.FromIncomingContext(le.ctx)
+	elapsed := time.Since(t)
+	tc := traceFromContext(le.ctx, metadataGetter(md))
+	ok, rate := le.cfg.sample(grpcSamplingParams(le.info.FullMethod, le.code(), elapsed, tc.traceID))
+	if !ok {
+		return
 	}
 
-	if le.cfg.withPeer {
-		if p, ok := peer.FromContext(le.ctx); ok {
-			e.Str("peer", p.Addr.String())
-		}
-	}
+	fs := le.cfg.fields(le.l.Log(), "grpc").
+		Str("protocol", "grpc").
+		Str("method", le.info.FullMethod)
+	le.cfg.writeGRPCStatus(fs, le.code())
+	le.cfg.writeTime(fs, t, elapsed)
+
+	le.cfg.writeMetadata(fs, md)
+	le.cfg.writePeer(fs, le.ctx)
+
 	if le.cfg.withRequest {
-		var m jsonpb.Marshaler
-		if p, ok := le.req.(proto.Message); ok {
-			if s, err := m.MarshalToString(p); err == nil {
-				e.Str("req", s)
-			}
-		}
+		le.cfg.writeMessage(fs, "req", le.req, le.cfg.requestMasks[le.info.FullMethod])
 	}
 	if le.cfg.withResponse {
-		var m jsonpb.Marshaler
-		if p, ok := (*le.res).(proto.Message); ok {
-			if s, err := m.MarshalToString(p); err == nil {
-				e.Str("res", s)
-			}
-		}
+		le.cfg.writeMessage(fs, "res", *le.res, le.cfg.responseMasks[le.info.FullMethod])
 	}
 
+	le.cfg.writeError(fs, *le.err, serverErrorOrigin(le.ctx, *le.err, le.panic != nil))
+	writePanic(fs, le.panic)
+	writeRequestID(fs, le.requestID)
+	le.cfg.writeTrace(fs, tc)
+	writeSampleRate(fs, rate)
+
+	e := fs.Event()
 	for _, f := range le.add {
 		f(e)
 	}
 
 	e.Send()
 }
+
+// grpcSamplingParams returns SamplingParams of a gRPC call.
+func grpcSamplingParams(method string, code codes.Code, elapsed time.Duration, traceID string) SamplingParams {
+	return SamplingParams{
+		Protocol: "grpc",
+		Method:   method,
+		Code:     code,
+		Elapsed:  elapsed,
+		TraceID:  traceID,
+	}
+}
+
+// writeMetadata writes metadata in md specified by WithMetadata.
+func (cfg *grpcConfig) writeMetadata(f *fields, md metadata.MD) {
+	for m, a := range cfg.metadata {
+		if ms := md.Get(m); len(ms) != 0 {
+			ms, ok := cfg.redactor.header(m, ms)
+			if !ok {
+				continue
+			}
+			if cfg.v2() {
+				f.AliasStrs(m, a, ms)
+			} else if b, err := json.Marshal(ms); err == nil {
+				f.Alias(m, a, string(b))
+			}
+		}
+	}
+}
+
+// writePeer writes the peer address in the context if WithPeer is set.
+func (cfg *grpcConfig) writePeer(f *fields, ctx context.Context) {
+	if cfg.withPeer {
+		if p, ok := peer.FromContext(ctx); ok {
+			f.Str("peer", p.Addr.String())
+		}
+	}
+}
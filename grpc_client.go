@@ -0,0 +1,188 @@
+package accesslog
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// DefaultGRPCClientLogger is default gRPC client Logger.
+var DefaultGRPCClientLogger = NewGRPCClientLogger(os.Stdout, NewDefaultGRPCClientLogFormatter())
+
+// GRPCClientLogger is logger for outgoing gRPC call logging.
+type GRPCClientLogger struct {
+	l *zerolog.Logger
+	f GRPCClientLogFormatter
+}
+
+// NewGRPCClientLogger returns a new GRPCClientLogger.
+func NewGRPCClientLogger(w io.Writer, f GRPCClientLogFormatter) *GRPCClientLogger {
+	l := zerolog.New(w)
+	return &GRPCClientLogger{
+		l: &l,
+		f: f,
+	}
+}
+
+// NewLogEntry returns a New LogEntry for a unary call.
+func (l *GRPCClientLogger) NewLogEntry(ctx context.Context, target, method string, req, res interface{}, err *error) LogEntry {
+	return l.f.NewLogEntry(l.l, ctx, target, method, req, res, err)
+}
+
+// NewStreamLogEntry returns a New LogEntry for a stream.
+func (l *GRPCClientLogger) NewStreamLogEntry(ctx context.Context, target, method string, desc *grpc.StreamDesc, stats *GRPCStreamStats, err *error) LogEntry {
+	return l.f.NewStreamLogEntry(l.l, ctx, target, method, desc, stats, err)
+}
+
+// GRPCClientLogFormatter is the interface for NewLogEntry and NewStreamLogEntry methods.
+type GRPCClientLogFormatter interface {
+	NewLogEntry(l *zerolog.Logger, ctx context.Context, target, method string, req, res interface{}, err *error) LogEntry
+	NewStreamLogEntry(l *zerolog.Logger, ctx context.Context, target, method string, desc *grpc.StreamDesc, stats *GRPCStreamStats, err *error) LogEntry
+}
+
+// DefaultGRPCClientLogFormatter is default GRPCClientLogFormatter.
+// It accepts the same options as DefaultGRPCLogFormatter, and WithMetadata captures outgoing metadata.
+type DefaultGRPCClientLogFormatter struct {
+	cfg *grpcConfig
+}
+
+// NewDefaultGRPCClientLogFormatter returns a new DefaultGRPCClientLogFormatter.
+func NewDefaultGRPCClientLogFormatter(opts ...grpcOption) *DefaultGRPCClientLogFormatter {
+	return &DefaultGRPCClientLogFormatter{cfg: newGRPCConfig(opts...)}
+}
+
+// NewLogEntry returns a New LogEntry for a unary call formatted in DefaultGRPCClientLogFormatter.
+func (f *DefaultGRPCClientLogFormatter) NewLogEntry(l *zerolog.Logger, ctx context.Context, target, method string, req, res interface{}, err *error) LogEntry {
+	return &DefaultGRPCClientLogEntry{
+		l:      l,
+		cfg:    f.cfg.forMethod(method),
+		ctx:    ctx,
+		target: target,
+		method: method,
+		req:    req,
+		res:    res,
+		err:    err,
+		add:    []func(e *zerolog.Event){},
+	}
+}
+
+// NewStreamLogEntry returns a New LogEntry for a stream formatted in DefaultGRPCClientLogFormatter.
+func (f *DefaultGRPCClientLogFormatter) NewStreamLogEntry(l *zerolog.Logger, ctx context.Context, target, method string, desc *grpc.StreamDesc, stats *GRPCStreamStats, err *error) LogEntry {
+	return &DefaultGRPCClientLogEntry{
+		l:      l,
+		cfg:    f.cfg.forMethod(method),
+		ctx:    ctx,
+		target: target,
+		method: method,
+		desc:   desc,
+		stats:  stats,
+		err:    err,
+		add:    []func(e *zerolog.Event){},
+	}
+}
+
+// DefaultGRPCClientLogEntry is the LogEntry formatted in DefaultGRPCClientLogFormatter.
+type DefaultGRPCClientLogEntry struct {
+	l      *zerolog.Logger
+	cfg    *grpcConfig
+	ctx    context.Context
+	target string
+	method string
+	req    interface{}
+	res    interface{}
+	desc   *grpc.StreamDesc
+	stats  *GRPCStreamStats
+	err    *error
+
+	mu   sync.Mutex
+	add  []func(e *zerolog.Event)
+	logs appLogs
+}
+
+// Add adds function for adding fields to log event.
+func (le *DefaultGRPCClientLogEntry) Add(f func(e *zerolog.Event)) {
+	if le == nil {
+		return
+	}
+
+	le.mu.Lock()
+	le.add = append(le.add, f)
+	le.mu.Unlock()
+}
+
+// AddAttrs implements AttrAdder.
+func (le *DefaultGRPCClientLogEntry) AddAttrs(attrs ...slog.Attr) {
+	le.Add(attrsFunc(attrs))
+}
+
+// appendLog implements appLogger.
+func (le *DefaultGRPCClientLogEntry) appendLog(key string, l appLog) {
+	le.logs.append(key, l, le.Add)
+}
+
+// Write writes a log.
+func (le *DefaultGRPCClientLogEntry) Write(t time.Time) {
+	if le.cfg.ignored(le.method) {
+		return
+	}
+
+	md, _ := metadata.FromOutgoingContext(le.ctx)
+	elapsed := time.Since(t)
+	tc := traceFromContext(le.ctx, metadataGetter(md))
+	ok, rate := le.cfg.sample(grpcSamplingParams(le.method, status.Code(*le.err), elapsed, tc.traceID))
+	if !ok {
+		return
+	}
+
+	fs := le.cfg.fields(le.l.Log(), "grpc").
+		Str("protocol", "grpc").
+		Str("side", "client").
+		Str("target", le.target).
+		Str("method", le.method)
+
+	if le.desc != nil {
+		fs.Str("stream", streamType(&grpc.StreamServerInfo{
+			IsClientStream: le.desc.ClientStreams,
+			IsServerStream: le.desc.ServerStreams,
+		}))
+	}
+
+	le.cfg.writeGRPCStatus(fs, status.Code(*le.err))
+	le.cfg.writeTime(fs, t, elapsed)
+
+	if le.stats != nil {
+		writeGRPCStreamStats(fs, le.stats)
+	}
+
+	le.cfg.writeMetadata(fs, md)
+
+	if le.cfg.withRequest && le.req != nil {
+		le.cfg.writeMessage(fs, "req", le.req, le.cfg.requestMasks[le.method])
+	}
+	if le.cfg.withResponse && le.res != nil && *le.err == nil {
+		le.cfg.writeMessage(fs, "res", le.res, le.cfg.responseMasks[le.method])
+	}
+
+	le.cfg.writeError(fs, *le.err, errorOrigin(le.ctx, *le.err, "server"))
+
+	writeRequestID(fs, le.cfg.clientRequestID(le.ctx, metadataGetter(md)))
+	le.cfg.writeTrace(fs, tc)
+	writeSampleRate(fs, rate)
+
+	e := fs.Event()
+	le.mu.Lock()
+	for _, f := range le.add {
+		f(e)
+	}
+	le.mu.Unlock()
+
+	e.Send()
+}
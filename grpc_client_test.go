@@ -0,0 +1,65 @@
+package accesslog
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestDefaultGRPCClientLogEntry_Write(t *testing.T) {
+	stats := new(GRPCStreamStats)
+	stats.Sent(3)
+	stats.Received(5)
+	stats.Received(7)
+
+	unavailable := status.Error(codes.Unavailable, "unavailable")
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "x-user", "u1")
+	tests := []struct {
+		name  string
+		opts  []grpcOption
+		entry func(f *DefaultGRPCClientLogFormatter, l *zerolog.Logger, err *error) LogEntry
+		err   error
+		want  []string
+	}{
+		{
+			name: "unary",
+			opts: []grpcOption{WithMetadata("x-user:user")},
+			entry: func(f *DefaultGRPCClientLogFormatter, l *zerolog.Logger, err *error) LogEntry {
+				return f.NewLogEntry(l, ctx, "dns:///svc", "/svc/M", nil, nil, err)
+			},
+			want: []string{`"side":"client"`, `"target":"dns:///svc"`, `"method":"/svc/M"`, `"status":"OK"`, `"user":"[\"u1\"]"`},
+		},
+		{
+			name: "stream",
+			opts: []grpcOption{WithErrorOrigin()},
+			entry: func(f *DefaultGRPCClientLogFormatter, l *zerolog.Logger, err *error) LogEntry {
+				return f.NewStreamLogEntry(l, ctx, "dns:///svc", "/svc/S", &grpc.StreamDesc{ServerStreams: true}, stats, err)
+			},
+			err:  unavailable,
+			want: []string{`"stream":"server_stream"`, `"status":"Unavailable"`, `"msgs_sent":1`, `"msgs_recv":2`, `"bytes_recv":12`, `"error_origin":"server"`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			l := zerolog.New(&buf)
+			err := tt.err
+			tt.entry(NewDefaultGRPCClientLogFormatter(tt.opts...), &l, &err).Write(time.Now())
+
+			got := buf.String()
+			for _, w := range tt.want {
+				if !strings.Contains(got, w) {
+					t.Errorf("log = %s, want to contain %s", got, w)
+				}
+			}
+		})
+	}
+}
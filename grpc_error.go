@@ -0,0 +1,86 @@
+package accesslog
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/status"
+)
+
+// serverErrorOrigin returns the origin of the error of a call handled by the server, or "" if the call succeeded.
+func serverErrorOrigin(ctx context.Context, err error, panicked bool) string {
+	if panicked {
+		return "panic"
+	}
+	return errorOrigin(ctx, err, "handler")
+}
+
+// errorOrigin returns "canceled" or "deadline_exceeded" if ctx is done, or otherwise def.
+// It returns "" if err is nil.
+func errorOrigin(ctx context.Context, err error, def string) string {
+	if err == nil {
+		return ""
+	}
+	if ctx != nil {
+		switch {
+		case errors.Is(ctx.Err(), context.Canceled):
+			return "canceled"
+		case errors.Is(ctx.Err(), context.DeadlineExceeded):
+			return "deadline_exceeded"
+		}
+	}
+	return def
+}
+
+// writeError writes the status message and error details of err specified by WithStatusMessage and WithErrorDetails,
+// and origin if WithErrorOrigin is set.
+func (cfg *grpcConfig) writeError(f *fields, err error, origin string) {
+	if cfg.withErrorOrigin && origin != "" {
+		f.Str("error_origin", origin)
+	}
+	if err == nil {
+		return
+	}
+
+	st := status.Convert(err)
+	if cfg.withStatusMessage && st.Message() != "" {
+		f.Str("error", st.Message())
+	}
+	if cfg.withErrorDetails {
+		cfg.writeErrorDetails(f, st.Details())
+	}
+}
+
+// writeErrorDetails writes the google.rpc error details of a status.
+// Details of other types or failing to be decoded are ignored.
+func (cfg *grpcConfig) writeErrorDetails(f *fields, details []interface{}) {
+	for _, d := range details {
+		switch d := d.(type) {
+		case *errdetails.ErrorInfo:
+			f.Str("error_reason", d.GetReason()).
+				Str("error_domain", d.GetDomain())
+		case *errdetails.BadRequest:
+			vs := make([]string, 0, len(d.GetFieldViolations()))
+			for _, v := range d.GetFieldViolations() {
+				vs = append(vs, v.GetField()+": "+v.GetDescription())
+			}
+			if cfg.v2() {
+				f.Strs("field_violations", vs)
+			} else if b, err := json.Marshal(vs); err == nil {
+				f.Str("field_violations", string(b))
+			}
+		case *errdetails.RetryInfo:
+			if rd := d.GetRetryDelay(); rd != nil {
+				f.Int64("retry_delay_ms", rd.AsDuration().Milliseconds())
+			}
+		case *errdetails.DebugInfo:
+			f.Str("debug_detail", d.GetDetail())
+			if len(d.GetStackEntries()) != 0 {
+				f.Str("debug_stack", strings.Join(d.GetStackEntries(), "\n"))
+			}
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package accesslog
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+func Test_serverErrorOrigin(t *testing.T) {
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	expired, cancel := context.WithDeadline(context.Background(), time.Now())
+	defer cancel()
+
+	err := status.Error(codes.Unavailable, "unavailable")
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		err      error
+		panicked bool
+		want     string
+	}{
+		{name: "ok", ctx: context.Background(), want: ""},
+		{name: "handler", ctx: context.Background(), err: err, want: "handler"},
+		{name: "canceled", ctx: canceled, err: err, want: "canceled"},
+		{name: "deadline exceeded", ctx: expired, err: err, want: "deadline_exceeded"},
+		{name: "panic", ctx: context.Background(), panicked: true, want: "panic"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := serverErrorOrigin(tt.ctx, tt.err, tt.panicked); got != tt.want {
+				t.Errorf("serverErrorOrigin() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDefaultGRPCLogEntry_error(t *testing.T) {
+	st, err := status.New(codes.InvalidArgument, "invalid name").WithDetails(
+		&errdetails.ErrorInfo{Reason: "NAME_INVALID", Domain: "example.com"},
+		&errdetails.BadRequest{FieldViolations: []*errdetails.BadRequest_FieldViolation{{Field: "name", Description: "empty"}}},
+		&errdetails.RetryInfo{RetryDelay: durationpb.New(1500 * time.Millisecond)},
+		&errdetails.DebugInfo{Detail: "detail", StackEntries: []string{"a", "b"}},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		opts    []grpcOption
+		want    []string
+		notWant []string
+	}{
+		{
+			name:    "default",
+			want:    []string{`"status":"InvalidArgument"`},
+			notWant: []string{`"error`, `"field_violations"`, `"retry_delay_ms"`, `"debug_`},
+		},
+		{
+			name: "all",
+			opts: []grpcOption{WithStatusMessage(), WithErrorDetails(), WithErrorOrigin()},
+			want: []string{
+				`"error_origin":"handler"`,
+				`"error":"invalid name"`,
+				`"error_reason":"NAME_INVALID","error_domain":"example.com"`,
+				`"field_violations":"[\"name: empty\"]"`,
+				`"retry_delay_ms":1500`,
+				`"debug_detail":"detail","debug_stack":"a\nb"`,
+			},
+		},
+		{
+			name: "v2",
+			opts: []grpcOption{WithErrorDetails(), WithSchemaVersion(SchemaV2)},
+			want: []string{`"field_violations":["name: empty"]`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			l := zerolog.New(&buf)
+			err := st.Err()
+			le := NewDefaultGRPCLogFormatter(tt.opts...).NewLogEntry(&l, context.Background(), nil, nil, &grpc.UnaryServerInfo{FullMethod: "/svc/M"}, &err)
+			le.Write(time.Now())
+
+			got := buf.String()
+			for _, w := range tt.want {
+				if !strings.Contains(got, w) {
+					t.Errorf("got %s, want %s", got, w)
+				}
+			}
+			for _, w := range tt.notWant {
+				if strings.Contains(got, w) {
+					t.Errorf("got %s, not want %s", got, w)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,222 @@
+package accesslog
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"unicode/utf8"
+
+	protov1 "github.com/golang/protobuf/proto"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// MessageMarshaler marshals messages captured by WithRequest and WithResponse which aren't proto.Message into JSON,
+// e.g. messages of gogo/protobuf or custom codecs.
+type MessageMarshaler interface {
+	MarshalMessage(v interface{}) ([]byte, error)
+}
+
+// MessageMarshalerFunc is the function implementing MessageMarshaler. e.g. MessageMarshalerFunc(json.Marshal)
+type MessageMarshalerFunc func(v interface{}) ([]byte, error)
+
+// MarshalMessage implements MessageMarshaler.
+func (f MessageMarshalerFunc) MarshalMessage(v interface{}) ([]byte, error) {
+	return f(v)
+}
+
+// FieldMask is the paths of fields of messages to be captured or dropped.
+// A path is the names of proto fields separated by dots, e.g. "user.name", like google.protobuf.FieldMask.
+// Paths not in a message are ignored.
+type FieldMask struct {
+	tree    maskTree
+	exclude bool
+}
+
+// maskTree is the tree of paths of a FieldMask by the names of fields.
+// An empty subtree means the whole field.
+type maskTree map[string]maskTree
+
+// IncludeFields returns the FieldMask capturing only the fields of paths.
+func IncludeFields(paths ...string) *FieldMask {
+	return &FieldMask{tree: newMaskTree(paths)}
+}
+
+// ExcludeFields returns the FieldMask dropping the fields of paths.
+func ExcludeFields(paths ...string) *FieldMask {
+	return &FieldMask{tree: newMaskTree(paths), exclude: true}
+}
+
+// newMaskTree returns the maskTree of paths.
+func newMaskTree(paths []string) maskTree {
+	tree := maskTree{}
+	for _, p := range paths {
+		t := tree
+		ns := strings.Split(p, ".")
+		for i, n := range ns {
+			sub, ok := t[n]
+			if ok && len(sub) == 0 {
+				// the whole field is already covered by a shorter path.
+				break
+			}
+			if i == len(ns)-1 {
+				t[n] = maskTree{}
+				break
+			}
+			if !ok {
+				sub = maskTree{}
+				t[n] = sub
+			}
+			t = sub
+		}
+	}
+	return tree
+}
+
+// apply clears the fields of m not captured by the mask.
+func (fm *FieldMask) apply(m protoreflect.Message) {
+	if fm.exclude {
+		excludeFields(m, fm.tree)
+	} else {
+		includeFields(m, fm.tree)
+	}
+}
+
+// includeFields clears the fields of m not in t.
+func includeFields(m protoreflect.Message, t maskTree) {
+	var cleared []protoreflect.FieldDescriptor
+	m.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		sub, ok := t[string(fd.Name())]
+		switch {
+		case !ok:
+			cleared = append(cleared, fd)
+		case len(sub) != 0:
+			rangeMessages(fd, v, func(m protoreflect.Message) {
+				includeFields(m, sub)
+			})
+		}
+		return true
+	})
+	for _, fd := range cleared {
+		m.Clear(fd)
+	}
+}
+
+// excludeFields clears the fields of m in t.
+func excludeFields(m protoreflect.Message, t maskTree) {
+	var cleared []protoreflect.FieldDescriptor
+	m.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		sub, ok := t[string(fd.Name())]
+		switch {
+		case !ok:
+		case len(sub) == 0:
+			cleared = append(cleared, fd)
+		default:
+			rangeMessages(fd, v, func(m protoreflect.Message) {
+				excludeFields(m, sub)
+			})
+		}
+		return true
+	})
+	for _, fd := range cleared {
+		m.Clear(fd)
+	}
+}
+
+// rangeMessages calls fn with the messages in the value v of fd.
+// Lists and maps of messages are traversed transparently.
+func rangeMessages(fd protoreflect.FieldDescriptor, v protoreflect.Value, fn func(m protoreflect.Message)) {
+	switch {
+	case fd.IsList() && fd.Message() != nil:
+		l := v.List()
+		for i := 0; i < l.Len(); i++ {
+			fn(l.Get(i).Message())
+		}
+	case fd.IsMap() && fd.MapValue().Message() != nil:
+		v.Map().Range(func(_ protoreflect.MapKey, mv protoreflect.Value) bool {
+			fn(mv.Message())
+			return true
+		})
+	case !fd.IsList() && !fd.IsMap() && fd.Message() != nil:
+		fn(v.Message())
+	}
+}
+
+// marshalMessage marshals v into JSON. proto.Message is marshaled by protojson after masked by mask and redacted,
+// and the other messages are marshaled by the MessageMarshaler. It returns false if v can't be marshaled.
+func (cfg *grpcConfig) marshalMessage(v interface{}, mask *FieldMask) ([]byte, bool) {
+	var m proto.Message
+	switch v := v.(type) {
+	case nil:
+		return nil, false
+	case proto.Message:
+		m = v
+	default:
+		if cfg.marshaler != nil {
+			b, err := cfg.marshaler.MarshalMessage(v)
+			return b, err == nil
+		}
+		v1, ok := v.(protov1.Message)
+		if !ok {
+			return nil, false
+		}
+		m = protov1.MessageV2(v1)
+	}
+
+	if mask != nil {
+		m = proto.Clone(m)
+		mask.apply(m.ProtoReflect())
+	}
+	m = cfg.redactor.proto(m)
+
+	o := cfg.protoJSON
+	o.Multiline, o.Indent = false, ""
+	b, err := o.Marshal(m)
+	if err != nil {
+		return nil, false
+	}
+	// protojson randomly adds spaces not to be depended on its output. They are removed to be stable.
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, b); err != nil {
+		return nil, false
+	}
+	return buf.Bytes(), true
+}
+
+// truncatedMessage is the object written instead of a message which can't be embedded as JSON,
+// so that the field is always an object.
+type truncatedMessage struct {
+	Truncated bool   `json:"truncated"`
+	JSON      string `json:"json"`
+}
+
+// writeMessage writes v in JSON as key, embedded as raw JSON. The message is masked by mask and redacted by the redactor.
+// A message truncated by WithMessageLimit, or not a JSON object, is written as truncatedMessage.
+func (cfg *grpcConfig) writeMessage(f *fields, key string, v interface{}, mask *FieldMask) {
+	b, ok := cfg.marshalMessage(v, mask)
+	if !ok {
+		return
+	}
+	if b, ok = cfg.redactor.json(b); !ok {
+		return
+	}
+
+	var m truncatedMessage
+	switch {
+	case cfg.messageLimit > 0 && len(b) > cfg.messageLimit:
+		// the message is cut at the start of a rune not to split it.
+		n := cfg.messageLimit
+		for n > 0 && !utf8.RuneStart(b[n]) {
+			n--
+		}
+		m = truncatedMessage{Truncated: true, JSON: string(b[:n])}
+	case len(b) != 0 && b[0] == '{' && json.Valid(b):
+		f.RawJSON(key, b)
+		return
+	default:
+		m = truncatedMessage{JSON: string(b)}
+	}
+	if b, err := json.Marshal(m); err == nil {
+		f.RawJSON(key, b)
+	}
+}
@@ -0,0 +1,133 @@
+package accesslog
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/sourcecontextpb"
+	"google.golang.org/protobuf/types/known/typepb"
+)
+
+func Test_newMaskTree(t *testing.T) {
+	tests := []struct {
+		name  string
+		paths []string
+		want  maskTree
+	}{
+		{
+			name:  "nested",
+			paths: []string{"a.b", "a.c", "d"},
+			want:  maskTree{"a": {"b": {}, "c": {}}, "d": {}},
+		},
+		{
+			name:  "shorter path first",
+			paths: []string{"a", "a.b"},
+			want:  maskTree{"a": {}},
+		},
+		{
+			name:  "shorter path last",
+			paths: []string{"a.b", "a"},
+			want:  maskTree{"a": {}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := newMaskTree(tt.paths); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("newMaskTree() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_grpcConfig_writeMessage(t *testing.T) {
+	msg := &typepb.Type{
+		Name: "foo",
+		Fields: []*typepb.Field{
+			{Name: "a", Number: 1},
+			{Name: "b", Number: 2},
+		},
+		SourceContext: &sourcecontextpb.SourceContext{FileName: "foo.proto"},
+	}
+
+	tests := []struct {
+		name string
+		opts []grpcOption
+		v    interface{}
+		mask *FieldMask
+		want string
+	}{
+		{
+			name: "proto message",
+			v:    msg,
+			want: `{"req":{"name":"foo","fields":[{"number":1,"name":"a"},{"number":2,"name":"b"}],"sourceContext":{"fileName":"foo.proto"}}}`,
+		},
+		{
+			name: "protojson options",
+			opts: []grpcOption{WithProtoJSONOptions(protojson.MarshalOptions{UseProtoNames: true, Multiline: true})},
+			v:    &typepb.Type{SourceContext: msg.SourceContext},
+			want: `{"req":{"source_context":{"file_name":"foo.proto"}}}`,
+		},
+		{
+			name: "include fields",
+			v:    msg,
+			mask: IncludeFields("name", "fields.number"),
+			want: `{"req":{"name":"foo","fields":[{"number":1},{"number":2}]}}`,
+		},
+		{
+			name: "exclude fields",
+			v:    msg,
+			mask: ExcludeFields("fields", "source_context.file_name"),
+			want: `{"req":{"name":"foo","sourceContext":{}}}`,
+		},
+		{
+			name: "truncated",
+			opts: []grpcOption{WithMessageLimit(10)},
+			v:    msg,
+			want: `{"req":{"truncated":true,"json":"{\"name\":\"f"}}`,
+		},
+		{
+			name: "truncated in a rune",
+			opts: []grpcOption{WithMessageLimit(13)},
+			v:    &typepb.Type{Name: "한글"},
+			want: `{"req":{"truncated":true,"json":"{\"name\":\"한"}}`,
+		},
+		{
+			name: "message marshaler",
+			opts: []grpcOption{WithMessageMarshaler(MessageMarshalerFunc(json.Marshal))},
+			v:    struct{ Name string }{Name: "foo"},
+			want: `{"req":{"Name":"foo"}}`,
+		},
+		{
+			name: "not object",
+			opts: []grpcOption{WithMessageMarshaler(MessageMarshalerFunc(json.Marshal))},
+			v:    "foo",
+			want: `{"req":{"truncated":false,"json":"\"foo\""}}`,
+		},
+		{
+			name: "not proto message",
+			v:    struct{ Name string }{Name: "foo"},
+			want: `{}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			cfg := newGRPCConfig(tt.opts...)
+			l := zerolog.New(&buf)
+			fs := cfg.fields(l.Log(), "grpc")
+			cfg.writeMessage(fs, "req", tt.v, tt.mask)
+			fs.Event().Send()
+
+			if got := bytes.TrimSpace(buf.Bytes()); string(got) != tt.want {
+				t.Errorf("writeMessage() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+	if got := msg.Fields[0].Name; got != "a" {
+		t.Errorf("the message is modified by masks: %v", msg)
+	}
+}
@@ -1,8 +1,23 @@
 package accesslog
 
-import "strings"
+import (
+	"strings"
 
-type grpcOption func(cfg *grpcConfig)
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+type grpcOption interface {
+	applyGRPC(cfg *grpcConfig)
+}
+
+// GRPCOption is the option for gRPC formatters, e.g. to collect options enabled conditionally.
+type GRPCOption = grpcOption
+
+type grpcOptionFunc func(cfg *grpcConfig)
+
+func (f grpcOptionFunc) applyGRPC(cfg *grpcConfig) {
+	f(cfg)
+}
 
 // WithIgnoredMethods specifies full methods to be ignored by the server side interceptor.
 // When an incoming request's full method is in ms, the request will not be captured.
@@ -11,9 +26,9 @@ func WithIgnoredMethods(ms ...string) grpcOption {
 	for _, e := range ms {
 		ims[e] = struct{}{}
 	}
-	return func(cfg *grpcConfig) {
+	return grpcOptionFunc(func(cfg *grpcConfig) {
 		cfg.ignoredMethods = ims
-	}
+	})
 }
 
 // WithMetadata specifies metadata to be captured by the logger. pseudo-headers in metadata also can be treated.
@@ -21,9 +36,9 @@ func WithIgnoredMethods(ms ...string) grpcOption {
 // e.g. "content-type:ct", this metadata will be logged like "ct": "[\"application/grpc\"]"
 func WithMetadata(ms ...string) grpcOption {
 	wms := metadataMap(ms)
-	return func(cfg *grpcConfig) {
+	return grpcOptionFunc(func(cfg *grpcConfig) {
 		cfg.metadata = wms
-	}
+	})
 }
 
 func metadataMap(ms []string) map[string]string {
@@ -52,21 +67,111 @@ func metadataMap(ms []string) map[string]string {
 
 // WithRequest specifies whether gRPC requests should be captured by the logger.
 func WithRequest() grpcOption {
-	return func(cfg *grpcConfig) {
+	return grpcOptionFunc(func(cfg *grpcConfig) {
 		cfg.withRequest = true
-	}
+	})
 }
 
 // WithResponse specifies whether gRPC responses should be captured by the logger.
 func WithResponse() grpcOption {
-	return func(cfg *grpcConfig) {
+	return grpcOptionFunc(func(cfg *grpcConfig) {
 		cfg.withResponse = true
-	}
+	})
 }
 
 // WithPeer specifies whether peer address should be captured by the logger.
 func WithPeer() grpcOption {
-	return func(cfg *grpcConfig) {
+	return grpcOptionFunc(func(cfg *grpcConfig) {
 		cfg.withPeer = true
-	}
+	})
+}
+
+// WithStatusMessage specifies whether the message of the status of a failed call should be captured as "error".
+func WithStatusMessage() grpcOption {
+	return grpcOptionFunc(func(cfg *grpcConfig) {
+		cfg.withStatusMessage = true
+	})
+}
+
+// WithErrorDetails specifies whether the google.rpc error details of the status of a failed call should be captured.
+// ErrorInfo is logged as "error_reason" and "error_domain", BadRequest as "field_violations",
+// RetryInfo as "retry_delay_ms", and DebugInfo as "debug_detail" and "debug_stack".
+func WithErrorDetails() grpcOption {
+	return grpcOptionFunc(func(cfg *grpcConfig) {
+		cfg.withErrorDetails = true
+	})
+}
+
+// WithErrorOrigin specifies whether the origin of the error of a failed call should be captured as "error_origin".
+// It is "canceled" or "deadline_exceeded" when the context of the call is done, "panic" when the server recovered a panic,
+// or otherwise "handler" for servers and "server" for clients.
+func WithErrorOrigin() grpcOption {
+	return grpcOptionFunc(func(cfg *grpcConfig) {
+		cfg.withErrorOrigin = true
+	})
+}
+
+// WithProtoJSONOptions specifies the options of protojson marshaling messages captured by WithRequest and WithResponse,
+// e.g. protojson.MarshalOptions{EmitUnpopulated: true, UseProtoNames: true}. Multiline and Indent are ignored.
+func WithProtoJSONOptions(o protojson.MarshalOptions) grpcOption {
+	return grpcOptionFunc(func(cfg *grpcConfig) {
+		cfg.protoJSON = o
+	})
+}
+
+// WithMessageMarshaler specifies the MessageMarshaler of messages which aren't proto.Message,
+// e.g. of gogo/protobuf or custom codecs. Field masks aren't applied to them.
+func WithMessageMarshaler(m MessageMarshaler) grpcOption {
+	return grpcOptionFunc(func(cfg *grpcConfig) {
+		cfg.marshaler = m
+	})
+}
+
+// WithMessageLimit specifies the maximum bytes of messages in JSON captured by WithRequest and WithResponse.
+// Messages over the limit are truncated, and logged as objects like {"truncated":true,"json":"{\"name\":..."}.
+// The default is 0, which means no limit.
+func WithMessageLimit(n int) grpcOption {
+	return grpcOptionFunc(func(cfg *grpcConfig) {
+		cfg.messageLimit = n
+	})
+}
+
+// WithRequestFieldMask specifies the FieldMask of requests of the full method captured by WithRequest.
+// e.g. WithRequestFieldMask("/foo.Foo/Bar", ExcludeFields("password", "profile.photo"))
+func WithRequestFieldMask(method string, m *FieldMask) grpcOption {
+	return grpcOptionFunc(func(cfg *grpcConfig) {
+		if cfg.requestMasks == nil {
+			cfg.requestMasks = map[string]*FieldMask{}
+		}
+		cfg.requestMasks[method] = m
+	})
+}
+
+// WithResponseFieldMask specifies the FieldMask of responses of the full method captured by WithResponse.
+func WithResponseFieldMask(method string, m *FieldMask) grpcOption {
+	return grpcOptionFunc(func(cfg *grpcConfig) {
+		if cfg.responseMasks == nil {
+			cfg.responseMasks = map[string]*FieldMask{}
+		}
+		cfg.responseMasks[method] = m
+	})
+}
+
+// WithGRPCRules specifies the rules overriding the options for gRPC methods matched by them. The first matched rule is applied.
+// Rules are evaluated once for each method, and the result is cached.
+// e.g. WithGRPCRules(GRPCRule{Method: "/grpc.health.v1.Health/*", Ignore: true}, GRPCRule{Method: "/payments.*/*", Request: ToggleOn})
+func WithGRPCRules(rules ...GRPCRule) grpcOption {
+	return grpcOptionFunc(func(cfg *grpcConfig) {
+		if cfg.rules == nil {
+			cfg.rules = &grpcRules{}
+		}
+		for _, r := range rules {
+			var md map[string]string
+			if r.Metadata != nil {
+				md = metadataMap(r.Metadata)
+			}
+			cfg.rules.rules = append(cfg.rules.rules, r)
+			cfg.rules.metadata = append(cfg.rules.metadata, md)
+		}
+	})
 }
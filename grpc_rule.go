@@ -0,0 +1,132 @@
+package accesslog
+
+import (
+	"path"
+	"sync"
+	"sync/atomic"
+)
+
+// maxCachedMethods is the maximum number of methods whose configurations are cached.
+// Methods can be unbounded, since clients can call any method of grpc.UnknownServiceHandler.
+const maxCachedMethods = 10000
+
+// GRPCRule is the rule overriding the options of the formatter for methods matched by Method.
+type GRPCRule struct {
+	// Method is the pattern of full methods of gRPC.
+	// See path.Match method how to set patterns, e.g. "/payments.*/*" or "/grpc.health.v1.Health/*".
+	Method string
+	// Ignore specifies whether calls of matched methods are ignored.
+	Ignore bool
+	// Sampler overrides WithSampler if not nil.
+	Sampler Sampler
+	// Request and Response override WithRequest and WithResponse.
+	Request  Toggle
+	Response Toggle
+	// Metadata overrides WithMetadata if not nil, in the same format as WithMetadata.
+	Metadata []string
+	// Redactor overrides WithRedactor if not nil.
+	Redactor *Redactor
+}
+
+// grpcRules is the rules of the formatter with the configurations of methods resolved by the rules.
+type grpcRules struct {
+	rules    []GRPCRule
+	metadata []map[string]string
+
+	once sync.Once
+	cfgs []*grpcConfig
+
+	// methods caches *grpcConfig by full method up to maxCachedMethods.
+	methods    sync.Map
+	numMethods int64
+}
+
+// match returns the index of the first rule matching method, or -1.
+func (rs *grpcRules) match(method string) int {
+	for i, r := range rs.rules {
+		if m, _ := path.Match(r.Method, method); m {
+			return i
+		}
+	}
+	return -1
+}
+
+// forMethod returns the configuration of method overridden by the first matched rule.
+// The configurations of the rules are shared by methods, and resolved methods are cached up to maxCachedMethods.
+func (cfg *grpcConfig) forMethod(method string) *grpcConfig {
+	rs := cfg.rules
+	if rs == nil {
+		return cfg
+	}
+	if c, ok := rs.methods.Load(method); ok {
+		return c.(*grpcConfig)
+	}
+
+	rs.once.Do(func() {
+		rs.cfgs = make([]*grpcConfig, len(rs.rules))
+		for i, r := range rs.rules {
+			rs.cfgs[i] = cfg.withRule(r, rs.metadata[i])
+		}
+	})
+	c := cfg
+	if i := rs.match(method); i != -1 {
+		c = rs.cfgs[i]
+	}
+	if atomic.LoadInt64(&rs.numMethods) < maxCachedMethods {
+		if _, loaded := rs.methods.LoadOrStore(method, c); !loaded {
+			atomic.AddInt64(&rs.numMethods, 1)
+		}
+	}
+	return c
+}
+
+// withRule returns a copy of cfg overridden by r. md is the metadata of r parsed by metadataMap.
+func (cfg *grpcConfig) withRule(r GRPCRule, md map[string]string) *grpcConfig {
+	c := *cfg
+	c.rules = nil
+	c.ignore = r.Ignore
+	if r.Sampler != nil {
+		c.sampler = r.Sampler
+	}
+	c.withRequest = r.Request.apply(c.withRequest)
+	c.withResponse = r.Response.apply(c.withResponse)
+	if md != nil {
+		c.metadata = md
+	}
+	if r.Redactor != nil {
+		c.redactor = r.Redactor
+	}
+	return &c
+}
+
+// ignored reports whether calls of method are ignored by WithIgnoredMethods or rules.
+func (cfg *grpcConfig) ignored(method string) bool {
+	_, ok := cfg.ignoredMethods[method]
+	return ok || cfg.ignore
+}
+
+// schemaConfig returns the configuration writing all fields written by cfg and the rules, for GRPCSchema.
+func (cfg *grpcConfig) schemaConfig() *grpcConfig {
+	if cfg.rules == nil {
+		return cfg
+	}
+	c := *cfg
+	c.metadata = make(map[string]string, len(cfg.metadata))
+	for k, a := range cfg.metadata {
+		c.metadata[k] = a
+	}
+	for i, r := range cfg.rules.rules {
+		if r.Ignore {
+			continue
+		}
+		if r.Sampler != nil && c.sampler == nil {
+			c.sampler = r.Sampler
+		}
+		c.withRequest = c.withRequest || r.Request == ToggleOn
+		c.withResponse = c.withResponse || r.Response == ToggleOn
+		for k, a := range cfg.rules.metadata[i] {
+			c.metadata[k] = a
+		}
+	}
+	return &c
+}
@@ -0,0 +1,91 @@
+package accesslog
+
+import (
+	"bytes"
+	"context"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/types/known/sourcecontextpb"
+)
+
+func TestWithGRPCRules(t *testing.T) {
+	f := NewDefaultGRPCLogFormatter(
+		WithRequest(),
+		WithMetadata("user-agent:ua"),
+		WithGRPCRules(
+			GRPCRule{Method: "/grpc.health.v1.Health/*", Ignore: true},
+			GRPCRule{Method: "/payments.*/*", Request: ToggleOff, Metadata: []string{"x-tenant:tenant"}},
+			GRPCRule{Method: "/payments.v1.Payments/*", Ignore: true},
+		),
+	)
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("user-agent", "grpc-go", "x-tenant", "foo"))
+	req := &sourcecontextpb.SourceContext{FileName: "foo.proto"}
+
+	tests := []struct {
+		method  string
+		want    []string
+		notWant []string
+	}{
+		{
+			method: "/grpc.health.v1.Health/Check",
+		},
+		{
+			method:  "/payments.v1.Payments/Pay",
+			want:    []string{`"tenant":"[\"foo\"]"`},
+			notWant: []string{`"req"`, `"ua"`},
+		},
+		{
+			method:  "/foo.Foo/Bar",
+			want:    []string{`"ua":"[\"grpc-go\"]"`, `"req":{"fileName":"foo.proto"}`},
+			notWant: []string{`"tenant"`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method, func(t *testing.T) {
+			var buf bytes.Buffer
+			l := zerolog.New(&buf)
+			var res interface{}
+			var err error
+			le := f.NewLogEntry(&l, ctx, req, &res, &grpc.UnaryServerInfo{FullMethod: tt.method}, &err)
+			le.Write(time.Now())
+
+			got := buf.String()
+			if len(tt.want) == 0 && got != "" {
+				t.Errorf("got %s, want ignored", got)
+			}
+			for _, w := range tt.want {
+				if !strings.Contains(got, w) {
+					t.Errorf("got %s, want %s", got, w)
+				}
+			}
+			for _, w := range tt.notWant {
+				if strings.Contains(got, w) {
+					t.Errorf("got %s, not want %s", got, w)
+				}
+			}
+		})
+	}
+
+	if f.cfg.forMethod("/foo.Foo/Bar") != f.cfg {
+		t.Error("forMethod() must return the config of the formatter for methods matching no rule")
+	}
+	if a, b := f.cfg.forMethod("/payments.v1.Payments/Pay"), f.cfg.forMethod("/payments.v1.Payments/Pay"); a != b {
+		t.Error("forMethod() must cache the config of the method")
+	}
+	for i := 0; i < maxCachedMethods+10; i++ {
+		f.cfg.forMethod("/payments.v1.Payments/" + strconv.Itoa(i))
+	}
+	if n := atomic.LoadInt64(&f.cfg.rules.numMethods); n != maxCachedMethods {
+		t.Errorf("cached methods = %d, want %d", n, maxCachedMethods)
+	}
+	if f.cfg.forMethod("/payments.v1.Payments/uncached") != f.cfg.forMethod("/payments.v1.Payments/Pay") {
+		t.Error("forMethod() must resolve methods not cached into the config of the rule")
+	}
+}
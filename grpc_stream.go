@@ -0,0 +1,225 @@
+package accesslog
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// GRPCStreamLogFormatter is the interface for NewStreamLogEntry method.
+type GRPCStreamLogFormatter interface {
+	NewStreamLogEntry(l *zerolog.Logger, ctx context.Context, info *grpc.StreamServerInfo, stats *GRPCStreamStats, err *error) LogEntry
+}
+
+// nopLogEntry is the LogEntry writing nothing, for streams of formatters not implementing GRPCStreamLogFormatter.
+type nopLogEntry struct{}
+
+// Write does nothing.
+func (nopLogEntry) Write(t time.Time) {}
+
+// Add does nothing.
+func (nopLogEntry) Add(f func(e *zerolog.Event)) {}
+
+// GRPCStreamStats holds the statistics of messages in a gRPC stream.
+// It is safe for concurrent use.
+type GRPCStreamStats struct {
+	msgsSent      int64
+	msgsReceived  int64
+	bytesSent     int64
+	bytesReceived int64
+
+	// unsizedSent and unsizedReceived count messages of unknown sizes.
+	unsizedSent     int64
+	unsizedReceived int64
+}
+
+// Sent records a message of the given size sent to the stream.
+// A negative size means the size of the message is unknown.
+func (s *GRPCStreamStats) Sent(size int) {
+	atomic.AddInt64(&s.msgsSent, 1)
+	if size < 0 {
+		atomic.AddInt64(&s.unsizedSent, 1)
+		return
+	}
+	atomic.AddInt64(&s.bytesSent, int64(size))
+}
+
+// Received records a message of the given size received from the stream.
+// A negative size means the size of the message is unknown.
+func (s *GRPCStreamStats) Received(size int) {
+	atomic.AddInt64(&s.msgsReceived, 1)
+	if size < 0 {
+		atomic.AddInt64(&s.unsizedReceived, 1)
+		return
+	}
+	atomic.AddInt64(&s.bytesReceived, int64(size))
+}
+
+// MsgsSent returns the number of messages sent.
+func (s *GRPCStreamStats) MsgsSent() int64 {
+	return atomic.LoadInt64(&s.msgsSent)
+}
+
+// MsgsReceived returns the number of messages received.
+func (s *GRPCStreamStats) MsgsReceived() int64 {
+	return atomic.LoadInt64(&s.msgsReceived)
+}
+
+// BytesSent returns the total size of messages sent.
+// ok is false if the size of any message sent is unknown.
+func (s *GRPCStreamStats) BytesSent() (n int64, ok bool) {
+	return atomic.LoadInt64(&s.bytesSent), atomic.LoadInt64(&s.unsizedSent) == 0
+}
+
+// BytesReceived returns the total size of messages received.
+// ok is false if the size of any message received is unknown.
+func (s *GRPCStreamStats) BytesReceived() (n int64, ok bool) {
+	return atomic.LoadInt64(&s.bytesReceived), atomic.LoadInt64(&s.unsizedReceived) == 0
+}
+
+// writeGRPCStreamStats writes the statistics of messages, omitting the sizes unknown.
+func writeGRPCStreamStats(e *fields, s *GRPCStreamStats) {
+	e.Int64("msgs_sent", s.MsgsSent()).
+		Int64("msgs_recv", s.MsgsReceived())
+	if n, ok := s.BytesSent(); ok {
+		e.Int64("bytes_sent", n)
+	}
+	if n, ok := s.BytesReceived(); ok {
+		e.Int64("bytes_recv", n)
+	}
+}
+
+// NewStreamLogEntry returns a New LogEntry for a stream formatted in DefaultGRPCLogFormatter.
+func (f *DefaultGRPCLogFormatter) NewStreamLogEntry(l *zerolog.Logger, ctx context.Context, info *grpc.StreamServerInfo, stats *GRPCStreamStats, err *error) LogEntry {
+	return &DefaultGRPCStreamLogEntry{
+		l:     l,
+		cfg:   f.cfg.forMethod(info.FullMethod),
+		ctx:   ctx,
+		info:  info,
+		stats: stats,
+		add:   []func(e *zerolog.Event){},
+		err:   err,
+
+		requestID: f.cfg.grpcRequestID(ctx),
+	}
+}
+
+// DefaultGRPCStreamLogEntry is the LogEntry for a stream formatted in DefaultGRPCLogFormatter.
+type DefaultGRPCStreamLogEntry struct {
+	l     *zerolog.Logger
+	cfg   *grpcConfig
+	ctx   context.Context
+	info  *grpc.StreamServerInfo
+	stats *GRPCStreamStats
+	err   *error
+
+	mu   sync.Mutex
+	add  []func(e *zerolog.Event)
+	logs appLogs
+
+	requestID string
+	panic     *panicInfo
+}
+
+// Add adds function for adding fields to log event.
+// It is safe to call Add from multiple goroutines handling the same stream.
+func (le *DefaultGRPCStreamLogEntry) Add(f func(e *zerolog.Event)) {
+	if le == nil {
+		return
+	}
+
+	le.mu.Lock()
+	le.add = append(le.add, f)
+	le.mu.Unlock()
+}
+
+// AddAttrs implements AttrAdder.
+func (le *DefaultGRPCStreamLogEntry) AddAttrs(attrs ...slog.Attr) {
+	le.Add(attrsFunc(attrs))
+}
+
+// appendLog implements appLogger.
+func (le *DefaultGRPCStreamLogEntry) appendLog(key string, l appLog) {
+	le.logs.append(key, l, le.Add)
+}
+
+// RequestID returns the request ID if WithRequestID is set.
+func (le *DefaultGRPCStreamLogEntry) RequestID() string {
+	return le.requestID
+}
+
+// RecordPanic implements PanicRecorder.
+func (le *DefaultGRPCStreamLogEntry) RecordPanic(v interface{}, stack []byte) {
+	le.panic = newPanicInfo(v, stack)
+}
+
+// code returns the code of the error, or codes.Internal if a panic is recovered.
+func (le *DefaultGRPCStreamLogEntry) code() codes.Code {
+	if le.panic != nil {
+		return codes.Internal
+	}
+	return status.Code(*le.err)
+}
+
+// Write writes a log.
+func (le *DefaultGRPCStreamLogEntry) Write(t time.Time) {
+	if le.cfg.ignored(le.info.FullMethod) {
+		return
+	}
+
+	md, _ := metadata.FromIncomingContext(le.ctx)
+	elapsed := time.Since(t)
+	tc := traceFromContext(le.ctx, metadataGetter(md))
+	ok, rate := le.cfg.sample(grpcSamplingParams(le.info.FullMethod, le.code(), elapsed, tc.traceID))
+	if !ok {
+		return
+	}
+
+	fs := le.cfg.fields(le.l.Log(), "grpc").
+		Str("protocol", "grpc").
+		Str("method", le.info.FullMethod).
+		Str("stream", streamType(le.info))
+	le.cfg.writeGRPCStatus(fs, le.code())
+	le.cfg.writeTime(fs, t, elapsed)
+	writeGRPCStreamStats(fs, le.stats)
+
+	le.cfg.writeMetadata(fs, md)
+	le.cfg.writePeer(fs, le.ctx)
+
+	le.cfg.writeError(fs, *le.err, serverErrorOrigin(le.ctx, *le.err, le.panic != nil))
+	writePanic(fs, le.panic)
+	writeRequestID(fs, le.requestID)
+	le.cfg.writeTrace(fs, tc)
+	writeSampleRate(fs, rate)
+
+	e := fs.Event()
+	le.mu.Lock()
+	for _, f := range le.add {
+		f(e)
+	}
+	le.mu.Unlock()
+
+	e.Send()
+}
+
+// streamType returns the kind of the stream described by info.
+func streamType(info *grpc.StreamServerInfo) string {
+	switch {
+	case info.IsClientStream && info.IsServerStream:
+		return "bidi_stream"
+	case info.IsClientStream:
+		return "client_stream"
+	case info.IsServerStream:
+		return "server_stream"
+	default:
+		return "unknown"
+	}
+}
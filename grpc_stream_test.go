@@ -0,0 +1,70 @@
+package accesslog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc"
+)
+
+func Test_streamType(t *testing.T) {
+	tests := []struct {
+		name string
+		info *grpc.StreamServerInfo
+		want string
+	}{
+		{
+			name: "bidi stream",
+			info: &grpc.StreamServerInfo{IsClientStream: true, IsServerStream: true},
+			want: "bidi_stream",
+		},
+		{
+			name: "client stream",
+			info: &grpc.StreamServerInfo{IsClientStream: true},
+			want: "client_stream",
+		},
+		{
+			name: "server stream",
+			info: &grpc.StreamServerInfo{IsServerStream: true},
+			want: "server_stream",
+		},
+		{
+			name: "unknown",
+			info: &grpc.StreamServerInfo{},
+			want: "unknown",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := streamType(tt.info); got != tt.want {
+				t.Errorf("streamType() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_writeGRPCStreamStats(t *testing.T) {
+	stats := new(GRPCStreamStats)
+	stats.Sent(3)
+	stats.Sent(4)
+	stats.Received(5)
+	stats.Received(-1)
+
+	var buf bytes.Buffer
+	l := zerolog.New(&buf)
+	fs := newGRPCConfig().fields(l.Log(), "grpc")
+	writeGRPCStreamStats(fs, stats)
+	fs.Event().Send()
+
+	got := buf.String()
+	for _, w := range []string{`"msgs_sent":2`, `"msgs_recv":2`, `"bytes_sent":7`} {
+		if !strings.Contains(got, w) {
+			t.Errorf("log = %s, want to contain %s", got, w)
+		}
+	}
+	if strings.Contains(got, "bytes_recv") {
+		t.Errorf("log = %s, want the unknown bytes_recv omitted", got)
+	}
+}
@@ -2,11 +2,12 @@ package accesslog
 
 import (
 	"io"
+	"log/slog"
 	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"path"
-	"strconv"
 	"strings"
 	"time"
 
@@ -19,6 +20,7 @@ var DefaultHTTPLogger = NewHTTPLogger(os.Stdout, NewDefaultHTTPLogFormatter())
 
 // HTTPLogger is logger for HTTP access logging.
 type HTTPLogger struct {
+	w io.Writer
 	l *zerolog.Logger
 	f HTTPLogFormatter
 }
@@ -27,6 +29,7 @@ type HTTPLogger struct {
 func NewHTTPLogger(w io.Writer, f HTTPLogFormatter) *HTTPLogger {
 	l := zerolog.New(w)
 	return &HTTPLogger{
+		w: w,
 		l: &l,
 		f: f,
 	}
@@ -34,6 +37,9 @@ func NewHTTPLogger(w io.Writer, f HTTPLogFormatter) *HTTPLogger {
 
 // NewLogEntry returns a New LogEntry.
 func (l *HTTPLogger) NewLogEntry(r *http.Request, ww chi_middleware.WrapResponseWriter) LogEntry {
+	if f, ok := l.f.(textWriterFormatter); ok {
+		return f.newTextLogEntry(l.w, r, ww)
+	}
 	return l.f.NewLogEntry(l.l, r, ww)
 }
 
@@ -43,9 +49,40 @@ type HTTPLogFormatter interface {
 }
 
 type httpConfig struct {
+	commonConfig
+
 	ignoredPaths map[string][]string
 	headers      map[string]string
 	withClientIP bool
+	withMethod   bool
+	withBytesIn  bool
+	withBytesOut bool
+	withProto    bool
+	withHost     bool
+	withScheme   bool
+	routePattern RoutePatternExtractor
+	routeAsPath  bool
+
+	withRequestBody  bool
+	withResponseBody bool
+	bodyLimit        int
+	bodyContentTypes []string
+
+	rules        *httpRules
+	ignore       bool
+	staticFields [][2]string
+}
+
+// newHTTPConfig returns a new httpConfig applied opts.
+func newHTTPConfig(opts ...httpOption) *httpConfig {
+	cfg := &httpConfig{
+		bodyLimit:        defaultBodyLimit,
+		bodyContentTypes: defaultBodyContentTypes,
+	}
+	for _, opt := range opts {
+		opt.applyHTTP(cfg)
+	}
+	return cfg
 }
 
 // DefaultHTTPLogFormatter is default HTTPLogFormatter.
@@ -55,32 +92,55 @@ type DefaultHTTPLogFormatter struct {
 
 // NewDefaultHTTPLogFormatter returns a new DefaultHTTPLogFormatter.
 func NewDefaultHTTPLogFormatter(opts ...httpOption) *DefaultHTTPLogFormatter {
-	cfg := new(httpConfig)
-	for _, fn := range opts {
-		fn(cfg)
-	}
-
-	return &DefaultHTTPLogFormatter{cfg: cfg}
+	return &DefaultHTTPLogFormatter{cfg: newHTTPConfig(opts...)}
 }
 
 // NewLogEntry returns a New LogEntry formatted in DefaultHTTPLogFormatter.
+// If WithBytesIn or WithRequestBody is set, the body of r is replaced to count and capture the bytes read by handlers.
+// If WithResponseBody is set, the response body is captured by teeing ww.
 func (f *DefaultHTTPLogFormatter) NewLogEntry(l *zerolog.Logger, r *http.Request, ww chi_middleware.WrapResponseWriter) LogEntry {
-	return &DefaultHTTPLogEntry{
+	le := &DefaultHTTPLogEntry{
 		cfg: f.cfg,
 		l:   l,
 		r:   r,
 		ww:  ww,
 		add: []func(e *zerolog.Event){},
 	}
+
+	if le.requestID = f.cfg.requestID(r.Header.Get(f.cfg.requestIDKey)); le.requestID != "" {
+		ww.Header().Set(f.cfg.requestIDKey, le.requestID)
+	}
+
+	withReqBody, withResBody := f.cfg.captureBodies(r)
+	captureReq := withReqBody && matchContentType(r.Header.Get("Content-Type"), f.cfg.bodyContentTypes)
+	if (f.cfg.withBytesIn || captureReq) && r.Body != nil && r.Body != http.NoBody {
+		le.body = &bodyReader{ReadCloser: r.Body}
+		if captureReq {
+			le.body.buf = &bodyBuffer{limit: f.cfg.bodyLimit}
+		}
+		r.Body = le.body
+	}
+	if withResBody {
+		le.resBody = &bodyBuffer{limit: f.cfg.bodyLimit}
+		ww.Tee(le.resBody)
+	}
+
+	return le
 }
 
 // DefaultHTTPLogEntry is the LogEntry formatted in DefaultHTTPLogFormatter.
 type DefaultHTTPLogEntry struct {
-	cfg *httpConfig
-	l   *zerolog.Logger
-	r   *http.Request
-	ww  chi_middleware.WrapResponseWriter
-	add []func(e *zerolog.Event)
+	cfg     *httpConfig
+	l       *zerolog.Logger
+	r       *http.Request
+	ww      chi_middleware.WrapResponseWriter
+	body    *bodyReader
+	resBody *bodyBuffer
+	add     []func(e *zerolog.Event)
+	logs    appLogs
+
+	requestID string
+	panic     *panicInfo
 }
 
 // Add adds function for adding fields to log event.
@@ -88,44 +148,117 @@ func (le *DefaultHTTPLogEntry) Add(f func(e *zerolog.Event)) {
 	le.add = append(le.add, f)
 }
 
+// AddAttrs implements AttrAdder.
+func (le *DefaultHTTPLogEntry) AddAttrs(attrs ...slog.Attr) {
+	le.Add(attrsFunc(attrs))
+}
+
+// appendLog implements appLogger.
+func (le *DefaultHTTPLogEntry) appendLog(key string, l appLog) {
+	le.logs.append(key, l, le.Add)
+}
+
+// RequestID returns the request ID if WithRequestID is set.
+func (le *DefaultHTTPLogEntry) RequestID() string {
+	return le.requestID
+}
+
+// RecordPanic implements PanicRecorder.
+func (le *DefaultHTTPLogEntry) RecordPanic(v interface{}, stack []byte) {
+	le.panic = newPanicInfo(v, stack)
+}
+
 // Write writes a log.
 func (le *DefaultHTTPLogEntry) Write(t time.Time) {
+	route := le.route()
+	// the rule is resolved here, since the route is known after handlers.
+	le.cfg = le.cfg.forRequest(le.r, route)
 	if le.isIgnored() {
 		return
 	}
 
-	e := le.l.Log().
+	p := le.r.URL.Path
+	if le.cfg.routeAsPath && route != "" {
+		p = route
+	}
+
+	elapsed := time.Since(t)
+	tc := traceFromContext(le.r.Context(), le.r.Header.Get)
+	sp := SamplingParams{
+		Protocol: "http",
+		Method:   le.r.Method,
+		Path:     le.r.URL.Path,
+		Status:   le.status(),
+		Elapsed:  elapsed,
+		TraceID:  tc.traceID,
+	}
+	if route != "" {
+		sp.Path, sp.Route = route, route
+	}
+	ok, rate := le.cfg.sample(sp)
+	if !ok {
+		return
+	}
+
+	fs := le.cfg.fields(le.l.Log(), "http").
 		Str("protocol", "http").
-		Str("path", le.r.URL.Path).
-		Str("status", strconv.Itoa(le.ww.Status())).
-		Str("ua", le.r.UserAgent()).
-		Str("time", t.UTC().Format(time.RFC3339Nano)).
-		Dur("elapsed(ms)", time.Since(t))
+		Str("path", p)
+	le.cfg.writeHTTPStatus(fs, le.status())
+	fs.Str("ua", le.r.UserAgent())
+	le.cfg.writeTime(fs, t, elapsed)
 
 	if val := le.r.URL.RawQuery; val != "" {
-		e.Str("qs", val)
+		fs.Str("qs", le.cfg.redactor.query(val))
 	}
+	le.cfg.writeURL(fs, le.r)
 
-	if whs := le.cfg.headers; len(whs) != 0 {
-		for h, a := range whs {
-			if val := le.r.Header.Get(h); val != "" {
-				n := h
-				if a != "" {
-					n = a
-				}
-				e.Str(n, val)
-			}
-		}
+	if !le.cfg.routeAsPath && route != "" {
+		fs.Str("route", route)
+	}
+	if le.cfg.withMethod {
+		fs.Str("method", le.r.Method)
+	}
+	if le.cfg.withBytesIn {
+		fs.Int64("bytes_in", le.bytesIn())
+	}
+	if le.cfg.withBytesOut {
+		fs.Int("bytes_out", le.ww.BytesWritten())
+	}
+	if le.cfg.withProto {
+		fs.Str("proto", le.r.Proto)
+	}
+	if le.cfg.withHost {
+		fs.Str("host", le.r.Host)
+	}
+	if le.cfg.withScheme {
+		fs.Str("scheme", scheme(le.r))
+	}
+
+	le.cfg.writeHeaders(fs, le.r.Header)
+
+	if le.body != nil && le.cfg.withRequestBody {
+		le.cfg.writeBody(fs, "req_body", le.r.Header.Get("Content-Type"), le.body.buf)
+	}
+	if ct := le.ww.Header().Get("Content-Type"); le.resBody != nil && le.cfg.withResponseBody && matchContentType(ct, le.cfg.bodyContentTypes) {
+		le.cfg.writeBody(fs, "res_body", ct, le.resBody)
 	}
 
 	if le.cfg.withClientIP {
 		if ip := clientIP(le.r.Header); ip != "" {
-			e.Str("client-ip", ip)
+			fs.Str("client-ip", ip)
 		} else if ip, _, err := net.SplitHostPort(strings.TrimSpace(le.r.RemoteAddr)); err == nil {
-			e.Str("client-ip", ip)
+			fs.Str("client-ip", ip)
 		}
 	}
 
+	le.cfg.writeFields(fs)
+
+	writePanic(fs, le.panic)
+	writeRequestID(fs, le.requestID)
+	le.cfg.writeTrace(fs, tc)
+	writeSampleRate(fs, rate)
+
+	e := fs.Event()
 	for _, f := range le.add {
 		f(e)
 	}
@@ -133,14 +266,50 @@ func (le *DefaultHTTPLogEntry) Write(t time.Time) {
 	e.Send()
 }
 
+// status returns the status of the response, or 500 if a panic is recovered.
+func (le *DefaultHTTPLogEntry) status() int {
+	if le.panic != nil {
+		return http.StatusInternalServerError
+	}
+	return le.ww.Status()
+}
+
+// route returns the route pattern matched by the request if WithRoutePattern is set.
+func (le *DefaultHTTPLogEntry) route() string {
+	if ex := le.cfg.routePattern; ex != nil {
+		return ex.RoutePattern(le.r)
+	}
+	return ""
+}
+
+// bytesIn returns the size of the request body.
+// The bytes read by handlers are preferred to Content-Length, since the body can be streamed.
+func (le *DefaultHTTPLogEntry) bytesIn() int64 {
+	n := le.r.ContentLength
+	if le.body != nil && le.body.n > n {
+		n = le.body.n
+	}
+	if n < 0 {
+		n = 0
+	}
+	return n
+}
+
 // isIgnored check whether a request path should be ignored
 func (le *DefaultHTTPLogEntry) isIgnored() bool {
-	if ips := le.cfg.ignoredPaths; len(ips) != 0 {
-		for _, ignorePath := range ips[le.r.Method] {
-			p := le.r.URL.Path
-			if p[0] != '/' {
-				p = "/" + p
-			}
+	return le.cfg.isIgnored(le.r.Method, le.r.URL.Path)
+}
+
+// isIgnored check whether a method and path should be ignored
+func (cfg *httpConfig) isIgnored(method, p string) bool {
+	if cfg.ignore {
+		return true
+	}
+	if ips := cfg.ignoredPaths; len(ips) != 0 {
+		if p == "" || p[0] != '/' {
+			p = "/" + p
+		}
+		for _, ignorePath := range ips[method] {
 			if m, _ := path.Match(ignorePath, p); m {
 				return true
 			}
@@ -149,6 +318,55 @@ func (le *DefaultHTTPLogEntry) isIgnored() bool {
 	return false
 }
 
+// writeHeaders writes headers in h specified by WithHeaders.
+func (cfg *httpConfig) writeHeaders(f *fields, h http.Header) {
+	if whs := cfg.headers; len(whs) != 0 {
+		for k, a := range whs {
+			if val := h.Get(k); val != "" {
+				vals, ok := cfg.redactor.header(k, []string{val})
+				if !ok {
+					continue
+				}
+				f.Alias(k, a, vals[0])
+			}
+		}
+	}
+}
+
+// writeURL writes the full URL of the request with the redacted query, if the naming names it, e.g. GCPNaming.
+func (cfg *httpConfig) writeURL(f *fields, r *http.Request) {
+	if !cfg.naming.named("http", "url") {
+		return
+	}
+	host := r.Host
+	if host == "" {
+		host = r.URL.Host
+	}
+	u := url.URL{
+		Scheme:   scheme(r),
+		Host:     host,
+		Path:     r.URL.Path,
+		RawPath:  r.URL.RawPath,
+		RawQuery: cfg.redactor.query(r.URL.RawQuery),
+	}
+	f.Str("url", u.String())
+}
+
+var xForwardedProto = http.CanonicalHeaderKey("X-Forwarded-Proto")
+
+// scheme returns the scheme of the request.
+// If the request is forwarded by a proxy, X-Forwarded-Proto header will be used.
+func scheme(r *http.Request) string {
+	if r.TLS != nil {
+		return "https"
+	} else if xfp := r.Header.Get(xForwardedProto); xfp != "" {
+		return strings.ToLower(xfp)
+	} else if r.URL.Scheme != "" {
+		return r.URL.Scheme
+	}
+	return "http"
+}
+
 var (
 	trueClientIP          = http.CanonicalHeaderKey("True-Client-IP")
 	xForwardedFor         = http.CanonicalHeaderKey("X-Forwarded-For")
@@ -0,0 +1,123 @@
+package accesslog
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime"
+	"path"
+	"strings"
+)
+
+const defaultBodyLimit = 4096
+
+// defaultBodyContentTypes is the media types of bodies to be captured by default.
+var defaultBodyContentTypes = []string{
+	"application/json",
+	"application/*+json",
+	"application/x-www-form-urlencoded",
+	"text/*",
+}
+
+// bodyReader is the request body counting the bytes read.
+// If buf is not nil, the bytes read are also captured into buf.
+type bodyReader struct {
+	io.ReadCloser
+	n   int64
+	buf *bodyBuffer
+}
+
+// Read reads from the underlying body and counts the bytes read.
+func (b *bodyReader) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.n += int64(n)
+	if b.buf != nil && n > 0 {
+		_, _ = b.buf.Write(p[:n])
+	}
+	return n, err
+}
+
+// bodyBuffer is the buffer capturing a body up to limit bytes.
+type bodyBuffer struct {
+	buf       bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+// Write writes p into the buffer. The bytes over the limit are discarded,
+// but it always reports that all of p is written not to break the writer teeing the body.
+func (b *bodyBuffer) Write(p []byte) (int, error) {
+	if n := b.limit - b.buf.Len(); n < len(p) {
+		b.truncated = true
+		if n > 0 {
+			b.buf.Write(p[:n])
+		}
+		return len(p), nil
+	}
+	b.buf.Write(p)
+	return len(p), nil
+}
+
+// writeBody writes the captured body as key. JSON bodies are embedded as raw JSON unless truncated.
+// Form and JSON bodies are redacted by the redactor, and JSON bodies which can't be redacted are omitted.
+func (cfg *httpConfig) writeBody(f *fields, key, contentType string, b *bodyBuffer) {
+	if b == nil {
+		return
+	}
+
+	if body := b.buf.Bytes(); len(body) != 0 {
+		switch {
+		case isJSON(contentType):
+			rb, ok := cfg.redactor.json(body)
+			switch {
+			case !ok:
+				// omitted, since the body can't be parsed to be redacted. e.g. truncated
+			case !b.truncated && json.Valid(rb):
+				f.RawJSON(key, rb)
+			default:
+				f.Str(key, string(rb))
+			}
+		case isForm(contentType):
+			f.Str(key, cfg.redactor.query(string(body)))
+		default:
+			f.Str(key, string(body))
+		}
+	}
+	// the flag is written even if nothing is captured, e.g. by WithBodyLimit(0).
+	if b.truncated {
+		f.Bool(key+"_truncated", true)
+	}
+}
+
+// matchContentType reports whether the media type of contentType matches one of patterns.
+// See path.Match method how to set patterns, e.g. "text/*".
+func matchContentType(contentType string, patterns []string) bool {
+	mt, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	for _, p := range patterns {
+		if m, _ := path.Match(p, mt); m {
+			return true
+		}
+	}
+	return false
+}
+
+// isJSON reports whether the media type of contentType is JSON.
+func isJSON(contentType string) bool {
+	mt, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mt == "application/json" || strings.HasSuffix(mt, "+json")
+}
+
+// isForm reports whether the media type of contentType is URL-encoded form.
+func isForm(contentType string) bool {
+	mt, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mt == "application/x-www-form-urlencoded"
+}
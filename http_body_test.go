@@ -0,0 +1,151 @@
+package accesslog
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/rs/zerolog"
+)
+
+func Test_bodyBuffer_Write(t *testing.T) {
+	tests := []struct {
+		name          string
+		limit         int
+		writes        []string
+		want          string
+		wantTruncated bool
+	}{
+		{
+			name:   "under limit",
+			limit:  10,
+			writes: []string{"hello"},
+			want:   "hello",
+		},
+		{
+			name:   "exactly limit",
+			limit:  5,
+			writes: []string{"he", "llo"},
+			want:   "hello",
+		},
+		{
+			name:          "over limit",
+			limit:         4,
+			writes:        []string{"he", "llo", "world"},
+			want:          "hell",
+			wantTruncated: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &bodyBuffer{limit: tt.limit}
+			for _, w := range tt.writes {
+				if n, err := b.Write([]byte(w)); n != len(w) || err != nil {
+					t.Fatalf("Write() = %v, %v", n, err)
+				}
+			}
+			if got := b.buf.String(); got != tt.want {
+				t.Errorf("buf = %v, want %v", got, tt.want)
+			}
+			if b.truncated != tt.wantTruncated {
+				t.Errorf("truncated = %v, want %v", b.truncated, tt.wantTruncated)
+			}
+		})
+	}
+}
+
+func Test_matchContentType(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		want        bool
+	}{
+		{
+			name:        "json",
+			contentType: "application/json; charset=utf-8",
+			want:        true,
+		},
+		{
+			name:        "json suffix",
+			contentType: "application/problem+json",
+			want:        true,
+		},
+		{
+			name:        "form",
+			contentType: "application/x-www-form-urlencoded",
+			want:        true,
+		},
+		{
+			name:        "text",
+			contentType: "text/plain",
+			want:        true,
+		},
+		{
+			name:        "binary",
+			contentType: "application/octet-stream",
+			want:        false,
+		},
+		{
+			name:        "empty",
+			contentType: "",
+			want:        false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matchContentType(tt.contentType, defaultBodyContentTypes); got != tt.want {
+				t.Errorf("matchContentType() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_httpConfig_writeBody(t *testing.T) {
+	tests := []struct {
+		name   string
+		limit  int
+		writes []string
+		want   string
+	}{
+		{
+			name:   "json",
+			limit:  20,
+			writes: []string{`{"a":1}`},
+			want:   `{"req_body":{"a":1}}`,
+		},
+		{
+			name:   "truncated",
+			limit:  4,
+			writes: []string{`"hello"`},
+			want:   `{"req_body":"\"hel","req_body_truncated":true}`,
+		},
+		{
+			name:   "nothing captured",
+			limit:  0,
+			writes: []string{`{"a":1}`},
+			want:   `{"req_body_truncated":true}`,
+		},
+		{
+			name: "empty",
+			want: `{}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &bodyBuffer{limit: tt.limit}
+			for _, w := range tt.writes {
+				_, _ = b.Write([]byte(w))
+			}
+
+			var buf bytes.Buffer
+			cfg := newHTTPConfig()
+			l := zerolog.New(&buf)
+			fs := cfg.fields(l.Log(), "http")
+			cfg.writeBody(fs, "req_body", "application/json", b)
+			fs.Event().Send()
+
+			if got := bytes.TrimSpace(buf.Bytes()); string(got) != tt.want {
+				t.Errorf("writeBody() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
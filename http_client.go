@@ -0,0 +1,198 @@
+package accesslog
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// DefaultHTTPClientLogger is default HTTP client Logger.
+var DefaultHTTPClientLogger = NewHTTPClientLogger(os.Stdout, NewDefaultHTTPClientLogFormatter())
+
+// HTTPClientLogger is logger for outgoing HTTP request logging.
+type HTTPClientLogger struct {
+	l *zerolog.Logger
+	f HTTPClientLogFormatter
+}
+
+// NewHTTPClientLogger returns a new HTTPClientLogger.
+func NewHTTPClientLogger(w io.Writer, f HTTPClientLogFormatter) *HTTPClientLogger {
+	l := zerolog.New(w)
+	return &HTTPClientLogger{
+		l: &l,
+		f: f,
+	}
+}
+
+// NewLogEntry returns a New LogEntry.
+func (l *HTTPClientLogger) NewLogEntry(r *http.Request, res **http.Response, err *error) LogEntry {
+	return l.f.NewLogEntry(l.l, r, res, err)
+}
+
+// HTTPClientLogFormatter is the interface for NewLogEntry method.
+type HTTPClientLogFormatter interface {
+	NewLogEntry(l *zerolog.Logger, r *http.Request, res **http.Response, err *error) LogEntry
+}
+
+// DefaultHTTPClientLogFormatter is default HTTPClientLogFormatter.
+// It accepts the same options as DefaultHTTPLogFormatter, and logs the method, the host and the path always.
+// Options of fields known only by servers or not captured from outgoing requests are ignored:
+// WithMethod, WithHost, WithBytesIn, WithBytesOut, WithProto, WithScheme, WithClientIP, WithRoutePattern,
+// WithRoutePatternAsPath, WithRequestBody, WithResponseBody, WithBodyLimit and WithBodyContentTypes.
+type DefaultHTTPClientLogFormatter struct {
+	cfg *httpConfig
+}
+
+// NewDefaultHTTPClientLogFormatter returns a new DefaultHTTPClientLogFormatter.
+func NewDefaultHTTPClientLogFormatter(opts ...httpOption) *DefaultHTTPClientLogFormatter {
+	return &DefaultHTTPClientLogFormatter{cfg: newHTTPConfig(opts...)}
+}
+
+// NewLogEntry returns a New LogEntry formatted in DefaultHTTPClientLogFormatter.
+func (f *DefaultHTTPClientLogFormatter) NewLogEntry(l *zerolog.Logger, r *http.Request, res **http.Response, err *error) LogEntry {
+	return &DefaultHTTPClientLogEntry{
+		cfg: f.cfg,
+		l:   l,
+		r:   r,
+		res: res,
+		err: err,
+		add: []func(e *zerolog.Event){},
+	}
+}
+
+// DefaultHTTPClientLogEntry is the LogEntry formatted in DefaultHTTPClientLogFormatter.
+type DefaultHTTPClientLogEntry struct {
+	cfg *httpConfig
+	l   *zerolog.Logger
+	r   *http.Request
+	res **http.Response
+	err *error
+
+	mu   sync.Mutex
+	add  []func(e *zerolog.Event)
+	logs appLogs
+}
+
+// Add adds function for adding fields to log event.
+func (le *DefaultHTTPClientLogEntry) Add(f func(e *zerolog.Event)) {
+	le.mu.Lock()
+	le.add = append(le.add, f)
+	le.mu.Unlock()
+}
+
+// RequestID returns the request ID sent with the request, or the one of the incoming request,
+// so that GetRequestID works in the context of the entry as well.
+func (le *DefaultHTTPClientLogEntry) RequestID() string {
+	if id := le.cfg.clientRequestID(le.r.Context(), le.r.Header.Get); id != "" {
+		return id
+	}
+	return GetRequestID(le.r.Context())
+}
+
+// AddAttrs implements AttrAdder.
+func (le *DefaultHTTPClientLogEntry) AddAttrs(attrs ...slog.Attr) {
+	le.Add(attrsFunc(attrs))
+}
+
+// appendLog implements appLogger.
+func (le *DefaultHTTPClientLogEntry) appendLog(key string, l appLog) {
+	le.logs.append(key, l, le.Add)
+}
+
+// Write writes a log.
+func (le *DefaultHTTPClientLogEntry) Write(t time.Time) {
+	le.cfg = le.cfg.forRequest(le.r, "")
+	if le.cfg.isIgnored(le.r.Method, le.r.URL.Path) {
+		return
+	}
+
+	elapsed := time.Since(t)
+	tc := traceFromContext(le.r.Context(), le.r.Header.Get)
+	sp := SamplingParams{
+		Protocol: "http",
+		Method:   le.r.Method,
+		Path:     le.r.URL.Path,
+		Elapsed:  elapsed,
+		TraceID:  tc.traceID,
+	}
+	if res := *le.res; res != nil {
+		sp.Status = res.StatusCode
+	} else if *le.err != nil {
+		// transport errors never produce a status code, but they are errors.
+		sp.Status = http.StatusBadGateway
+	}
+	ok, rate := le.cfg.sample(sp)
+	if !ok {
+		return
+	}
+
+	fs := le.cfg.fields(le.l.Log(), "http").
+		Str("protocol", "http").
+		Str("side", "client").
+		Str("method", le.r.Method).
+		Str("host", le.r.URL.Host).
+		Str("path", le.r.URL.Path)
+
+	if res := *le.res; res != nil {
+		le.cfg.writeHTTPStatus(fs, res.StatusCode)
+	}
+
+	le.cfg.writeTime(fs, t, elapsed)
+
+	if val := le.r.URL.RawQuery; val != "" {
+		fs.Str("qs", le.cfg.redactor.query(val))
+	}
+	le.cfg.writeURL(fs, le.r)
+
+	le.cfg.writeHeaders(fs, le.r.Header)
+	le.cfg.writeFields(fs)
+
+	if err := *le.err; err != nil {
+		fs.Str("error", err.Error()).
+			Str("error_kind", transportErrorKind(err))
+	}
+
+	writeRequestID(fs, le.cfg.clientRequestID(le.r.Context(), le.r.Header.Get))
+	le.cfg.writeTrace(fs, tc)
+	writeSampleRate(fs, rate)
+
+	e := fs.Event()
+	le.mu.Lock()
+	for _, f := range le.add {
+		f(e)
+	}
+	le.mu.Unlock()
+
+	e.Send()
+}
+
+// transportErrorKind classifies an error returned by http.RoundTripper.
+func transportErrorKind(err error) string {
+	var dnsErr *net.DNSError
+	var netErr net.Error
+	switch {
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	case errors.As(err, &dnsErr):
+		return "dns"
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return "connection_refused"
+	case errors.Is(err, syscall.ECONNRESET):
+		return "connection_reset"
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return "timeout"
+	default:
+		return "unknown"
+	}
+}
@@ -0,0 +1,62 @@
+package accesslog
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/url"
+	"os"
+	"syscall"
+	"testing"
+)
+
+func Test_transportErrorKind(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{
+			name: "canceled",
+			err:  &url.Error{Op: "Get", URL: "http://a", Err: context.Canceled},
+			want: "canceled",
+		},
+		{
+			name: "deadline exceeded",
+			err:  &url.Error{Op: "Get", URL: "http://a", Err: context.DeadlineExceeded},
+			want: "timeout",
+		},
+		{
+			name: "dns",
+			err:  &net.OpError{Op: "dial", Err: &net.DNSError{Err: "no such host", Name: "a"}},
+			want: "dns",
+		},
+		{
+			name: "connection refused",
+			err:  &net.OpError{Op: "dial", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)},
+			want: "connection_refused",
+		},
+		{
+			name: "connection reset",
+			err:  &net.OpError{Op: "read", Err: os.NewSyscallError("read", syscall.ECONNRESET)},
+			want: "connection_reset",
+		},
+		{
+			name: "net timeout",
+			err:  &net.OpError{Op: "dial", Err: os.ErrDeadlineExceeded},
+			want: "timeout",
+		},
+		{
+			name: "unknown",
+			err:  errors.New("unknown"),
+			want: "unknown",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := transportErrorKind(tt.err); got != tt.want {
+				t.Errorf("transportErrorKind() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -4,14 +4,25 @@ import (
 	"strings"
 )
 
-type httpOption func(cfg *httpConfig)
+type httpOption interface {
+	applyHTTP(cfg *httpConfig)
+}
+
+// HTTPOption is the option for HTTP formatters, e.g. to collect options enabled conditionally.
+type HTTPOption = httpOption
+
+type httpOptionFunc func(cfg *httpConfig)
+
+func (f httpOptionFunc) applyHTTP(cfg *httpConfig) {
+	f(cfg)
+}
 
 // WithIgnoredPaths specifies methods and paths to be ignored by the logger.
 // See path.Match method how to set path patterns
 func WithIgnoredPaths(ips map[string][]string) httpOption {
-	return func(cfg *httpConfig) {
+	return httpOptionFunc(func(cfg *httpConfig) {
 		cfg.ignoredPaths = ips
-	}
+	})
 }
 
 // WithHeaders specifies headers to be captured by the logger. pseudo-headers also can be treated.
@@ -19,9 +30,9 @@ func WithIgnoredPaths(ips map[string][]string) httpOption {
 // e.g. "content-type:ct", this metadata will be logged like "ct": "application/json"
 func WithHeaders(hs ...string) httpOption {
 	whs := headerMap(hs)
-	return func(cfg *httpConfig) {
+	return httpOptionFunc(func(cfg *httpConfig) {
 		cfg.headers = whs
-	}
+	})
 }
 
 func headerMap(hs []string) map[string]string {
@@ -50,7 +61,120 @@ func headerMap(hs []string) map[string]string {
 
 // WithClientIP specifies whether client ip should be captured by the logger.
 func WithClientIP() httpOption {
-	return func(cfg *httpConfig) {
+	return httpOptionFunc(func(cfg *httpConfig) {
 		cfg.withClientIP = true
+	})
+}
+
+// WithMethod specifies whether the HTTP method should be captured by the logger.
+func WithMethod() httpOption {
+	return httpOptionFunc(func(cfg *httpConfig) {
+		cfg.withMethod = true
+	})
+}
+
+// WithBytesIn specifies whether the size of the request body should be captured by the logger.
+// Streamed request bodies without Content-Length are counted while handlers read them.
+func WithBytesIn() httpOption {
+	return httpOptionFunc(func(cfg *httpConfig) {
+		cfg.withBytesIn = true
+	})
+}
+
+// WithBytesOut specifies whether the size of the response body should be captured by the logger.
+func WithBytesOut() httpOption {
+	return httpOptionFunc(func(cfg *httpConfig) {
+		cfg.withBytesOut = true
+	})
+}
+
+// WithProto specifies whether the protocol version (e.g. HTTP/1.1) should be captured by the logger.
+func WithProto() httpOption {
+	return httpOptionFunc(func(cfg *httpConfig) {
+		cfg.withProto = true
+	})
+}
+
+// WithHost specifies whether the host of the request should be captured by the logger.
+func WithHost() httpOption {
+	return httpOptionFunc(func(cfg *httpConfig) {
+		cfg.withHost = true
+	})
+}
+
+// WithScheme specifies whether the scheme of the request should be captured by the logger.
+func WithScheme() httpOption {
+	return httpOptionFunc(func(cfg *httpConfig) {
+		cfg.withScheme = true
+	})
+}
+
+// WithRoutePattern specifies whether the route pattern matched by the request should be captured by the logger as "route".
+// e.g. "/users/{id}" for "/users/123". If ex is nil, ChiRoutePattern is used.
+func WithRoutePattern(ex RoutePatternExtractor) httpOption {
+	if ex == nil {
+		ex = ChiRoutePattern
 	}
+	return httpOptionFunc(func(cfg *httpConfig) {
+		cfg.routePattern = ex
+		cfg.routeAsPath = false
+	})
+}
+
+// WithRoutePatternAsPath specifies that the route pattern matched by the request should be logged as "path" instead of the raw path.
+// The raw path is logged when no route pattern is matched. If ex is nil, ChiRoutePattern is used.
+func WithRoutePatternAsPath(ex RoutePatternExtractor) httpOption {
+	if ex == nil {
+		ex = ChiRoutePattern
+	}
+	return httpOptionFunc(func(cfg *httpConfig) {
+		cfg.routePattern = ex
+		cfg.routeAsPath = true
+	})
+}
+
+// WithRequestBody specifies whether request bodies should be captured by the logger as "req_body".
+// Only the bytes read by handlers are captured. JSON bodies are logged as raw JSON unless truncated.
+func WithRequestBody() httpOption {
+	return httpOptionFunc(func(cfg *httpConfig) {
+		cfg.withRequestBody = true
+	})
+}
+
+// WithResponseBody specifies whether response bodies should be captured by the logger as "res_body".
+// JSON bodies are logged as raw JSON unless truncated.
+func WithResponseBody() httpOption {
+	return httpOptionFunc(func(cfg *httpConfig) {
+		cfg.withResponseBody = true
+	})
+}
+
+// WithBodyLimit specifies the maximum bytes of bodies captured by WithRequestBody and WithResponseBody. The default is 4096.
+// Bodies over the limit are truncated, and logged with "req_body_truncated" or "res_body_truncated".
+func WithBodyLimit(n int) httpOption {
+	return httpOptionFunc(func(cfg *httpConfig) {
+		cfg.bodyLimit = n
+	})
+}
+
+// WithBodyContentTypes specifies media types of bodies to be captured by WithRequestBody and WithResponseBody.
+// See path.Match method how to set patterns, e.g. "text/*".
+// The default is "application/json", "application/*+json", "application/x-www-form-urlencoded" and "text/*".
+func WithBodyContentTypes(cts ...string) httpOption {
+	return httpOptionFunc(func(cfg *httpConfig) {
+		cfg.bodyContentTypes = cts
+	})
+}
+
+// WithHTTPRules specifies the rules overriding the options for requests matched by them.
+// The most specific matched rule is applied, and rules of the same specificity are applied in the order given.
+// See HTTPRule for the specificity.
+// e.g. WithHTTPRules(HTTPRule{Path: "/api/**", Headers: []string{"x-tenant-id:tenant"}}, HTTPRule{Host: "admin.*", Ignore: true})
+func WithHTTPRules(rules ...HTTPRule) httpOption {
+	return httpOptionFunc(func(cfg *httpConfig) {
+		if cfg.rules == nil {
+			cfg.rules = &httpRules{}
+		}
+		cfg.rules.rules = append(cfg.rules.rules, rules...)
+	})
 }
@@ -0,0 +1,51 @@
+package accesslog
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/gorilla/mux"
+)
+
+// RoutePatternExtractor is the interface for extracting the route pattern matched by a request.
+// e.g. "/users/{id}" for "/users/123"
+type RoutePatternExtractor interface {
+	RoutePattern(r *http.Request) string
+}
+
+// RoutePatternFunc is an adapter to allow the use of ordinary functions as RoutePatternExtractor.
+type RoutePatternFunc func(r *http.Request) string
+
+// RoutePattern calls f(r).
+func (f RoutePatternFunc) RoutePattern(r *http.Request) string {
+	return f(r)
+}
+
+// ChiRoutePattern extracts the route pattern matched by chi router.
+// It works when the logging middleware is used inside chi router, e.g. with Router.Use.
+var ChiRoutePattern RoutePatternExtractor = RoutePatternFunc(func(r *http.Request) string {
+	if rctx := chi.RouteContext(r.Context()); rctx != nil {
+		return rctx.RoutePattern()
+	}
+	return ""
+})
+
+// GorillaMuxRoutePattern extracts the route template matched by gorilla/mux router.
+// It works when the logging middleware is used with Router.Use, since the route is set after matching.
+var GorillaMuxRoutePattern RoutePatternExtractor = RoutePatternFunc(func(r *http.Request) string {
+	if route := mux.CurrentRoute(r); route != nil {
+		if tpl, err := route.GetPathTemplate(); err == nil {
+			return tpl
+		}
+	}
+	return ""
+})
+
+// ServeMuxRoutePattern returns RoutePatternExtractor for the pattern of m matched by a request.
+// With Go 1.22 or later, the pattern may contain a method and wildcards like "GET /users/{id}".
+func ServeMuxRoutePattern(m *http.ServeMux) RoutePatternExtractor {
+	return RoutePatternFunc(func(r *http.Request) string {
+		_, p := m.Handler(r)
+		return p
+	})
+}
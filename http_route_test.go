@@ -0,0 +1,46 @@
+package accesslog
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/gorilla/mux"
+)
+
+func TestChiRoutePattern(t *testing.T) {
+	var got string
+	r := chi.NewRouter()
+	r.Get("/users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		got = ChiRoutePattern.RoutePattern(r)
+	})
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/123", nil))
+
+	if want := "/users/{id}"; got != want {
+		t.Errorf("RoutePattern() = %v, want %v", got, want)
+	}
+}
+
+func TestGorillaMuxRoutePattern(t *testing.T) {
+	var got string
+	r := mux.NewRouter()
+	r.HandleFunc("/users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		got = GorillaMuxRoutePattern.RoutePattern(r)
+	})
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/123", nil))
+
+	if want := "/users/{id}"; got != want {
+		t.Errorf("RoutePattern() = %v, want %v", got, want)
+	}
+}
+
+func TestServeMuxRoutePattern(t *testing.T) {
+	m := http.NewServeMux()
+	m.HandleFunc("/users/", func(w http.ResponseWriter, r *http.Request) {})
+
+	got := ServeMuxRoutePattern(m).RoutePattern(httptest.NewRequest(http.MethodGet, "/users/123", nil))
+	if want := "/users/"; got != want {
+		t.Errorf("RoutePattern() = %v, want %v", got, want)
+	}
+}
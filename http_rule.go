@@ -0,0 +1,339 @@
+package accesslog
+
+import (
+	"net"
+	"net/http"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// HTTPRule is the rule overriding the options of the formatter for requests matched by it.
+// Empty conditions match all requests.
+type HTTPRule struct {
+	// Method is the method of requests, e.g. "GET".
+	Method string
+	// Host is the pattern of hosts without ports, e.g. "*.example.com".
+	// See path.Match method how to set patterns.
+	Host string
+	// Path is the pattern of paths. "**" matches zero or more segments,
+	// and the other segments are matched by path.Match, e.g. "/api/**" or "/users/*/avatar".
+	Path string
+	// PathRegexp is the regular expression of paths, matched in addition to Path.
+	PathRegexp *regexp.Regexp
+	// Route is the route pattern extracted by WithRoutePattern, e.g. "/users/{id}".
+	Route string
+
+	// Ignore specifies whether matched requests are ignored.
+	Ignore bool
+	// Sampler overrides WithSampler if not nil.
+	Sampler Sampler
+	// Fields are the static fields written to logs of matched requests, e.g. {"team": "payments"}.
+	Fields map[string]string
+	// Headers overrides WithHeaders if not nil, in the same format as WithHeaders.
+	Headers []string
+	// RequestBody and ResponseBody override WithRequestBody and WithResponseBody.
+	// Since the route is known after handlers, bodies are captured if rules with Route may enable them,
+	// and written only if the rule matched at last enables them.
+	RequestBody  Toggle
+	ResponseBody Toggle
+}
+
+// specificity returns the specificity of the rule to be ordered.
+// A rule with an exact path is more specific than one with a pattern, which is more specific than
+// one with a regular expression or without path. Then a longer literal prefix of the path is more specific,
+// and then a rule with more of the method, host and route is more specific.
+func (r HTTPRule) specificity() [3]int {
+	var kind int
+	switch {
+	case r.Path != "" && !hasMeta(r.Path):
+		kind = 3
+	case r.Path != "":
+		kind = 2
+	case r.PathRegexp != nil:
+		kind = 1
+	}
+	var conds int
+	for _, c := range []string{r.Method, r.Host, r.Route} {
+		if c != "" {
+			conds++
+		}
+	}
+	return [3]int{kind, len(literalPrefix(r.Path)), conds}
+}
+
+// match reports whether the rule matches the request. If routed is false, the route isn't known yet
+// and the rule is matched regardless of Route.
+func (r HTTPRule) match(method, host, p, route string, routed bool) bool {
+	if r.Method != "" && r.Method != method {
+		return false
+	}
+	if r.Host != "" {
+		if m, _ := path.Match(r.Host, host); !m {
+			return false
+		}
+	}
+	if r.Path != "" && !matchPath(r.Path, p) {
+		return false
+	}
+	if r.PathRegexp != nil && !r.PathRegexp.MatchString(p) {
+		return false
+	}
+	return !routed || r.Route == "" || r.Route == route
+}
+
+// hasMeta reports whether the path pattern has any special characters of path.Match.
+func hasMeta(p string) bool {
+	return strings.ContainsAny(p, `*?[\`)
+}
+
+// literalPrefix returns the prefix of the path pattern without special characters.
+func literalPrefix(p string) string {
+	if i := strings.IndexAny(p, `*?[\`); i != -1 {
+		return p[:i]
+	}
+	return p
+}
+
+// matchPath reports whether p is matched by the pattern, where "**" matches zero or more segments.
+func matchPath(pattern, p string) bool {
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(p, "/"))
+}
+
+// matchSegments reports whether the segments of a path are matched by the segments of a pattern.
+func matchSegments(ps, ss []string) bool {
+	for len(ps) != 0 {
+		if ps[0] == "**" {
+			for i := 0; i <= len(ss); i++ {
+				if matchSegments(ps[1:], ss[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(ss) == 0 {
+			return false
+		}
+		if m, _ := path.Match(ps[0], ss[0]); !m {
+			return false
+		}
+		ps, ss = ps[1:], ss[1:]
+	}
+	return len(ss) == 0
+}
+
+// firstSegment returns the first segment of the path, e.g. "api" of "/api/users".
+func firstSegment(p string) string {
+	p = strings.TrimPrefix(p, "/")
+	if i := strings.IndexByte(p, '/'); i != -1 {
+		return p[:i]
+	}
+	return p
+}
+
+// httpRules is the rules ordered by specificity, indexed by paths not to try every rule on every request.
+type httpRules struct {
+	rules []HTTPRule
+
+	once     sync.Once
+	cfgs     []*httpConfig
+	bodies   bool
+	exact    map[string][]int
+	segments map[string][]int
+	others   []int
+}
+
+// compile orders the rules, builds the indexes and the configurations of the rules overriding base.
+func (rs *httpRules) compile(base *httpConfig) {
+	rs.once.Do(func() {
+		sort.SliceStable(rs.rules, func(i, j int) bool {
+			a, b := rs.rules[i].specificity(), rs.rules[j].specificity()
+			for k := range a {
+				if a[k] != b[k] {
+					return a[k] > b[k]
+				}
+			}
+			return false
+		})
+
+		rs.exact = map[string][]int{}
+		rs.segments = map[string][]int{}
+		for i, r := range rs.rules {
+			rs.cfgs = append(rs.cfgs, base.withRule(r))
+			rs.bodies = rs.bodies || r.RequestBody == ToggleOn || r.ResponseBody == ToggleOn
+
+			prefix := literalPrefix(r.Path)
+			switch {
+			case r.Path != "" && !hasMeta(r.Path):
+				rs.exact[r.Path] = append(rs.exact[r.Path], i)
+			case strings.Contains(strings.TrimPrefix(prefix, "/"), "/"):
+				// the first segment is literal.
+				s := firstSegment(prefix)
+				rs.segments[s] = append(rs.segments[s], i)
+			default:
+				rs.others = append(rs.others, i)
+			}
+		}
+	})
+}
+
+// match returns the index of the most specific rule matching the request, or -1.
+func (rs *httpRules) match(method, host, p, route string, routed bool) int {
+	m := -1
+	rs.candidates(p, func(i int) bool {
+		if rs.rules[i].match(method, host, p, route, routed) {
+			m = i
+			return false
+		}
+		return true
+	})
+	return m
+}
+
+// candidates calls fn with the indexes of the rules which may match the path p in the order of the rules,
+// until fn returns false. The candidates are the rules of the exact path, of the first segment of the path, and the others.
+func (rs *httpRules) candidates(p string, fn func(i int) bool) {
+	lists := [3][]int{rs.exact[p], rs.segments[firstSegment(p)], rs.others}
+	for {
+		// the lists are in the order of the rules, so they are merged in the order.
+		min := -1
+		for k, l := range lists {
+			if len(l) != 0 && (min == -1 || l[0] < lists[min][0]) {
+				min = k
+			}
+		}
+		if min == -1 {
+			return
+		}
+		i := lists[min][0]
+		lists[min] = lists[min][1:]
+		if !fn(i) {
+			return
+		}
+	}
+}
+
+// forRequest returns the configuration of the request overridden by the most specific matched rule.
+func (cfg *httpConfig) forRequest(r *http.Request, route string) *httpConfig {
+	if cfg.rules == nil {
+		return cfg
+	}
+	cfg.rules.compile(cfg)
+	if i := cfg.rules.match(r.Method, requestHost(r), requestPath(r), route, true); i != -1 {
+		return cfg.rules.cfgs[i]
+	}
+	return cfg
+}
+
+// captureBodies reports whether the bodies of the request should be captured by the formatter or any rule
+// which may match the request. Rules with Route may match, since the route isn't known yet.
+func (cfg *httpConfig) captureBodies(r *http.Request) (req, res bool) {
+	req, res = cfg.withRequestBody, cfg.withResponseBody
+	if cfg.rules == nil {
+		return req, res
+	}
+	cfg.rules.compile(cfg)
+	if !cfg.rules.bodies {
+		return req, res
+	}
+	host, p := requestHost(r), requestPath(r)
+	cfg.rules.candidates(p, func(i int) bool {
+		if cfg.rules.rules[i].match(r.Method, host, p, "", false) {
+			req = req || cfg.rules.cfgs[i].withRequestBody
+			res = res || cfg.rules.cfgs[i].withResponseBody
+		}
+		return !(req && res)
+	})
+	return req, res
+}
+
+// withRule returns a copy of cfg overridden by r.
+func (cfg *httpConfig) withRule(r HTTPRule) *httpConfig {
+	c := *cfg
+	c.rules = nil
+	c.ignore = r.Ignore
+	if r.Sampler != nil {
+		c.sampler = r.Sampler
+	}
+	if r.Headers != nil {
+		c.headers = headerMap(r.Headers)
+	}
+	c.withRequestBody = r.RequestBody.apply(c.withRequestBody)
+	c.withResponseBody = r.ResponseBody.apply(c.withResponseBody)
+	if len(r.Fields) != 0 {
+		c.staticFields = make([][2]string, 0, len(r.Fields))
+		for k, v := range r.Fields {
+			c.staticFields = append(c.staticFields, [2]string{k, v})
+		}
+		sort.Slice(c.staticFields, func(i, j int) bool {
+			return c.staticFields[i][0] < c.staticFields[j][0]
+		})
+	}
+	return &c
+}
+
+// writeFields writes the static fields of the rule.
+func (cfg *httpConfig) writeFields(f *fields) {
+	for _, kv := range cfg.staticFields {
+		f.Str(kv[0], kv[1])
+	}
+}
+
+// schemaConfig returns the configuration writing all fields written by cfg and the rules, for HTTPSchema.
+func (cfg *httpConfig) schemaConfig() *httpConfig {
+	if cfg.rules == nil {
+		return cfg
+	}
+	c := *cfg
+	c.headers = make(map[string]string, len(cfg.headers))
+	for k, a := range cfg.headers {
+		c.headers[k] = a
+	}
+	fs := map[string]string{}
+	for _, r := range cfg.rules.rules {
+		if r.Ignore {
+			continue
+		}
+		if r.Sampler != nil && c.sampler == nil {
+			c.sampler = r.Sampler
+		}
+		c.withRequestBody = c.withRequestBody || r.RequestBody == ToggleOn
+		c.withResponseBody = c.withResponseBody || r.ResponseBody == ToggleOn
+		for k, a := range headerMap(r.Headers) {
+			c.headers[k] = a
+		}
+		for k, v := range r.Fields {
+			fs[k] = v
+		}
+	}
+	c.staticFields = nil
+	for k := range fs {
+		c.staticFields = append(c.staticFields, [2]string{k})
+	}
+	sort.Slice(c.staticFields, func(i, j int) bool {
+		return c.staticFields[i][0] < c.staticFields[j][0]
+	})
+	return &c
+}
+
+// requestHost returns the host of the request without the port. The host of the URL is used for outgoing requests.
+func requestHost(r *http.Request) string {
+	host := r.Host
+	if host == "" {
+		host = r.URL.Host
+	}
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		return h
+	}
+	return host
+}
+
+// requestPath returns the path of the request starting with "/".
+func requestPath(r *http.Request) string {
+	if p := r.URL.Path; p != "" && p[0] == '/' {
+		return p
+	}
+	return "/" + r.URL.Path
+}
@@ -0,0 +1,209 @@
+package accesslog
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	chi_middleware "github.com/go-chi/chi/v5/middleware"
+	"github.com/rs/zerolog"
+)
+
+func Test_matchPath(t *testing.T) {
+	tests := []struct {
+		pattern string
+		p       string
+		want    bool
+	}{
+		{pattern: "/api/**", p: "/api", want: true},
+		{pattern: "/api/**", p: "/api/v1/users", want: true},
+		{pattern: "/api/**", p: "/apis/v1", want: false},
+		{pattern: "/**/avatar", p: "/users/1/avatar", want: true},
+		{pattern: "/users/*/avatar", p: "/users/1/avatar", want: true},
+		{pattern: "/users/*/avatar", p: "/users/1/2/avatar", want: false},
+		{pattern: "/users/*", p: "/users", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.p, func(t *testing.T) {
+			if got := matchPath(tt.pattern, tt.p); got != tt.want {
+				t.Errorf("matchPath() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithHTTPRules(t *testing.T) {
+	f := NewDefaultHTTPLogFormatter(
+		WithHeaders("user-agent:agent"),
+		WithRoutePattern(RoutePatternFunc(func(r *http.Request) string {
+			if strings.HasPrefix(r.URL.Path, "/users/") {
+				return "/users/{id}"
+			}
+			return ""
+		})),
+		WithHTTPRules(
+			HTTPRule{Path: "/api/**", Fields: map[string]string{"team": "api"}, Headers: []string{"x-tenant:tenant"}},
+			HTTPRule{Path: "/api/internal/**", Ignore: true},
+			HTTPRule{Path: "/api/health", Ignore: true},
+			HTTPRule{Host: "admin.*", Ignore: true},
+			HTTPRule{PathRegexp: regexp.MustCompile(`^/v\d+/`), Fields: map[string]string{"versioned": "true"}},
+			HTTPRule{Route: "/users/{id}", Method: http.MethodPost, RequestBody: ToggleOn},
+		),
+	)
+
+	tests := []struct {
+		name    string
+		method  string
+		target  string
+		body    string
+		want    []string
+		notWant []string
+	}{
+		{
+			name:    "glob",
+			target:  "http://example.com/api/users",
+			want:    []string{`"tenant":"foo"`, `"team":"api"`},
+			notWant: []string{`"agent"`},
+		},
+		{
+			name:   "more specific glob",
+			target: "http://example.com/api/internal/jobs",
+		},
+		{
+			name:   "exact path",
+			target: "http://example.com/api/health",
+		},
+		{
+			name:   "host",
+			target: "http://admin.example.com:8080/",
+		},
+		{
+			name:   "regexp",
+			target: "http://example.com/v2/users",
+			want:   []string{`"agent":"test"`, `"versioned":"true"`},
+		},
+		{
+			name:   "route",
+			method: http.MethodPost,
+			target: "http://example.com/users/1",
+			body:   `{"name":"foo"}`,
+			want:   []string{`"req_body":{"name":"foo"}`},
+		},
+		{
+			name:    "route of other method",
+			target:  "http://example.com/users/1",
+			body:    `{"name":"foo"}`,
+			notWant: []string{`"req_body"`},
+		},
+		{
+			name:    "no rule",
+			target:  "http://example.com/",
+			want:    []string{`"agent":"test"`},
+			notWant: []string{`"team"`, `"tenant"`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			method := tt.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			r := httptest.NewRequest(method, tt.target, strings.NewReader(tt.body))
+			r.Header.Set("Content-Type", "application/json")
+			r.Header.Set("User-Agent", "test")
+			r.Header.Set("X-Tenant", "foo")
+
+			var buf bytes.Buffer
+			l := zerolog.New(&buf)
+			ww := chi_middleware.NewWrapResponseWriter(httptest.NewRecorder(), 1)
+			le := f.NewLogEntry(&l, r, ww)
+			if tt.body != "" {
+				var b bytes.Buffer
+				_, _ = b.ReadFrom(r.Body)
+			}
+			ww.WriteHeader(http.StatusOK)
+			le.Write(time.Now())
+
+			got := buf.String()
+			if len(tt.want) == 0 && len(tt.notWant) == 0 && got != "" {
+				t.Errorf("got %s, want ignored", got)
+			}
+			for _, w := range tt.want {
+				if !strings.Contains(got, w) {
+					t.Errorf("got %s, want %s", got, w)
+				}
+			}
+			for _, w := range tt.notWant {
+				if strings.Contains(got, w) {
+					t.Errorf("got %s, not want %s", got, w)
+				}
+			}
+		})
+	}
+}
+
+func Test_httpRules_match(t *testing.T) {
+	rs := &httpRules{rules: []HTTPRule{
+		{Path: "/**"},
+		{Path: "/api/**"},
+		{Path: "/api/**", Method: http.MethodGet},
+		{Path: "/api/users"},
+		{PathRegexp: regexp.MustCompile(`^/api`)},
+	}}
+	rs.compile(newHTTPConfig())
+
+	tests := []struct {
+		method string
+		p      string
+		want   HTTPRule
+	}{
+		{method: http.MethodGet, p: "/api/users", want: HTTPRule{Path: "/api/users"}},
+		{method: http.MethodGet, p: "/api/posts", want: HTTPRule{Path: "/api/**", Method: http.MethodGet}},
+		{method: http.MethodPost, p: "/api/posts", want: HTTPRule{Path: "/api/**"}},
+		{method: http.MethodGet, p: "/", want: HTTPRule{Path: "/**"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.p, func(t *testing.T) {
+			i := rs.match(tt.method, "example.com", tt.p, "", true)
+			if i == -1 {
+				t.Fatal("match() = -1")
+			}
+			if got := rs.rules[i]; got.Path != tt.want.Path || got.Method != tt.want.Method {
+				t.Errorf("match() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_httpConfig_captureBodies(t *testing.T) {
+	f := NewDefaultHTTPLogFormatter(WithHTTPRules(
+		HTTPRule{Path: "/api/users", RequestBody: ToggleOn},
+		HTTPRule{Path: "/api/**", Route: "/api/posts/{id}", ResponseBody: ToggleOn},
+		HTTPRule{Path: "/admin/**", Method: http.MethodPost, RequestBody: ToggleOn},
+	))
+
+	tests := []struct {
+		method  string
+		p       string
+		wantReq bool
+		wantRes bool
+	}{
+		{method: http.MethodPost, p: "/api/users", wantReq: true, wantRes: true},
+		{method: http.MethodGet, p: "/api/posts/1", wantRes: true},
+		{method: http.MethodGet, p: "/admin/x"},
+		{method: http.MethodPost, p: "/admin/x", wantReq: true},
+		{method: http.MethodPost, p: "/other"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.p, func(t *testing.T) {
+			req, res := f.cfg.captureBodies(httptest.NewRequest(tt.method, tt.p, nil))
+			if req != tt.wantReq || res != tt.wantRes {
+				t.Errorf("captureBodies() = %v, %v, want %v, %v", req, res, tt.wantReq, tt.wantRes)
+			}
+		})
+	}
+}
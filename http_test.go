@@ -1,8 +1,11 @@
 package accesslog
 
 import (
+	"crypto/tls"
+	"io"
 	"net/http"
 	"net/url"
+	"strings"
 	"testing"
 )
 
@@ -178,3 +181,85 @@ func Test_clientIP(t *testing.T) {
 		})
 	}
 }
+
+func TestDefaultHTTPLogEntry_bytesIn(t *testing.T) {
+	tests := []struct {
+		name          string
+		contentLength int64
+		body          string
+		read          bool
+		want          int64
+	}{
+		{
+			name:          "content length",
+			contentLength: 5,
+			body:          "hello",
+			want:          5,
+		},
+		{
+			name:          "streamed body",
+			contentLength: -1,
+			body:          "hello",
+			read:          true,
+			want:          5,
+		},
+		{
+			name:          "streamed body not read",
+			contentLength: -1,
+			body:          "hello",
+			want:          0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &http.Request{
+				ContentLength: tt.contentLength,
+				Body:          io.NopCloser(strings.NewReader(tt.body)),
+			}
+			f := NewDefaultHTTPLogFormatter(WithBytesIn())
+			le := f.NewLogEntry(nil, r, nil).(*DefaultHTTPLogEntry)
+			if tt.read {
+				if _, err := io.ReadAll(r.Body); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if got := le.bytesIn(); got != tt.want {
+				t.Errorf("bytesIn() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_scheme(t *testing.T) {
+	tests := []struct {
+		name string
+		r    *http.Request
+		want string
+	}{
+		{
+			name: "tls",
+			r:    &http.Request{TLS: &tls.ConnectionState{}, URL: &url.URL{}},
+			want: "https",
+		},
+		{
+			name: "x-forwarded-proto",
+			r: &http.Request{
+				Header: http.Header{"X-Forwarded-Proto": []string{"HTTPS"}},
+				URL:    &url.URL{},
+			},
+			want: "https",
+		},
+		{
+			name: "plain",
+			r:    &http.Request{URL: &url.URL{}},
+			want: "http",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := scheme(tt.r); got != tt.want {
+				t.Errorf("scheme() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,321 @@
+package accesslog
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	chi_middleware "github.com/go-chi/chi/v5/middleware"
+	"github.com/rs/zerolog"
+)
+
+const (
+	// CommonLogFormat is the template of Common Log Format.
+	CommonLogFormat = `$remote_addr - $remote_user [$time_local] "$request" $status $body_bytes_sent`
+	// CombinedLogFormat is the template of Combined Log Format, the default log_format of nginx.
+	CombinedLogFormat = CommonLogFormat + ` "$http_referer" "$http_user_agent"`
+)
+
+// textWriterFormatter is implemented by formatters writing plain text to the writer of HTTPLogger instead of zerolog.
+type textWriterFormatter interface {
+	newTextLogEntry(w io.Writer, r *http.Request, ww chi_middleware.WrapResponseWriter) LogEntry
+}
+
+// textVar renders a variable of a template.
+type textVar func(le *TextHTTPLogEntry) string
+
+// textVars are the variables of templates in the syntax of log_format of nginx.
+var textVars = map[string]textVar{
+	"remote_addr": func(le *TextHTTPLogEntry) string {
+		if ip, _, err := net.SplitHostPort(strings.TrimSpace(le.r.RemoteAddr)); err == nil {
+			return ip
+		}
+		return le.r.RemoteAddr
+	},
+	"remote_user": func(le *TextHTTPLogEntry) string {
+		u, _, _ := le.r.BasicAuth()
+		return u
+	},
+	"time_local": func(le *TextHTTPLogEntry) string {
+		return le.now.In(le.f.loc).Format("02/Jan/2006:15:04:05 -0700")
+	},
+	"time_iso8601": func(le *TextHTTPLogEntry) string {
+		return le.now.In(le.f.loc).Format("2006-01-02T15:04:05-07:00")
+	},
+	"msec": func(le *TextHTTPLogEntry) string {
+		return strconv.FormatFloat(float64(le.now.UnixNano()/int64(time.Millisecond))/1e3, 'f', 3, 64)
+	},
+	"request": func(le *TextHTTPLogEntry) string {
+		return le.r.Method + " " + le.requestURI() + " " + le.r.Proto
+	},
+	"request_method": func(le *TextHTTPLogEntry) string {
+		return le.r.Method
+	},
+	"request_uri": func(le *TextHTTPLogEntry) string {
+		return le.requestURI()
+	},
+	"uri": func(le *TextHTTPLogEntry) string {
+		return le.r.URL.Path
+	},
+	"args":         textArgs,
+	"query_string": textArgs,
+	"server_protocol": func(le *TextHTTPLogEntry) string {
+		return le.r.Proto
+	},
+	"host": func(le *TextHTTPLogEntry) string {
+		return le.r.Host
+	},
+	"scheme": func(le *TextHTTPLogEntry) string {
+		return scheme(le.r)
+	},
+	"status": func(le *TextHTTPLogEntry) string {
+		return strconv.Itoa(le.status())
+	},
+	"body_bytes_sent": func(le *TextHTTPLogEntry) string {
+		return strconv.Itoa(le.ww.BytesWritten())
+	},
+	"request_time": func(le *TextHTTPLogEntry) string {
+		return strconv.FormatFloat(le.elapsed.Seconds(), 'f', 3, 64)
+	},
+	"request_id": func(le *TextHTTPLogEntry) string {
+		return le.requestID
+	},
+}
+
+// textArgs renders the redacted query string.
+func textArgs(le *TextHTTPLogEntry) string {
+	return le.f.cfg.redactor.query(le.r.URL.RawQuery)
+}
+
+// textSegment is a literal or a variable of a template.
+type textSegment struct {
+	lit string
+	v   textVar
+}
+
+// TextHTTPLogFormatter is the HTTPLogFormatter writing plain text lines rendered from a template
+// in the syntax of log_format of nginx, e.g. CombinedLogFormat, instead of JSON.
+//
+// Variables are $remote_addr, $remote_user, $time_local, $time_iso8601, $msec, $request, $request_method,
+// $request_uri, $uri, $args, $query_string, $server_protocol, $host, $scheme, $status, $body_bytes_sent,
+// $request_time, $request_id and $http_<header>, e.g. $http_user_agent. Names can be enclosed in braces like ${status}.
+// Like nginx, $time_local, $time_iso8601 and $msec are the time the line is written. Empty values are written as "-",
+// and quotes, backslashes, control characters and bytes from 0x7f are escaped as \xHH like nginx.
+//
+// Lines are written to the writer of HTTPLogger as they are, so fields added by LogEntry.Add are ignored.
+// Of the options, WithIgnoredPaths, WithSampler, WithRedactor and WithRequestID are applied.
+type TextHTTPLogFormatter struct {
+	cfg      *httpConfig
+	segments []textSegment
+	loc      *time.Location
+	now      func() time.Time
+}
+
+// NewTextHTTPLogFormatter returns a new TextHTTPLogFormatter rendering format.
+// It returns an error if format has unknown variables.
+func NewTextHTTPLogFormatter(format string, opts ...httpOption) (*TextHTTPLogFormatter, error) {
+	segs, err := parseTextFormat(format)
+	if err != nil {
+		return nil, fmt.Errorf("new text http log formatter: %w", err)
+	}
+	return &TextHTTPLogFormatter{
+		cfg:      newHTTPConfig(opts...),
+		segments: segs,
+		loc:      time.Local,
+		now:      time.Now,
+	}, nil
+}
+
+// parseTextFormat parses the template into segments.
+func parseTextFormat(format string) ([]textSegment, error) {
+	var segs []textSegment
+	var lit strings.Builder
+	for i := 0; i < len(format); {
+		if format[i] != '$' {
+			lit.WriteByte(format[i])
+			i++
+			continue
+		}
+
+		var name string
+		if i+1 < len(format) && format[i+1] == '{' {
+			j := strings.IndexByte(format[i+2:], '}')
+			if j == -1 {
+				return nil, fmt.Errorf("unclosed variable at %d", i)
+			}
+			name = format[i+2 : i+2+j]
+			i += j + 3
+		} else {
+			j := i + 1
+			for j < len(format) && isTextVarByte(format[j]) {
+				j++
+			}
+			name = format[i+1 : j]
+			i = j
+		}
+		if name == "" {
+			lit.WriteByte('$')
+			continue
+		}
+
+		v, err := textVariable(name)
+		if err != nil {
+			return nil, err
+		}
+		if lit.Len() != 0 {
+			segs = append(segs, textSegment{lit: lit.String()})
+			lit.Reset()
+		}
+		segs = append(segs, textSegment{v: v})
+	}
+	if lit.Len() != 0 {
+		segs = append(segs, textSegment{lit: lit.String()})
+	}
+	return segs, nil
+}
+
+// isTextVarByte reports whether c can be a part of the name of variables.
+func isTextVarByte(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') || c == '_'
+}
+
+// textVariable returns the variable of name.
+func textVariable(name string) (textVar, error) {
+	if strings.HasPrefix(name, "http_") && len(name) > len("http_") {
+		h := strings.ReplaceAll(name[len("http_"):], "_", "-")
+		return func(le *TextHTTPLogEntry) string {
+			val := le.r.Header.Get(h)
+			if val == "" {
+				return ""
+			}
+			vals, ok := le.f.cfg.redactor.header(h, []string{val})
+			if !ok {
+				return ""
+			}
+			return vals[0]
+		}, nil
+	}
+	if v, ok := textVars[name]; ok {
+		return v, nil
+	}
+	return nil, fmt.Errorf("unknown variable $%s", name)
+}
+
+// NewLogEntry returns a New LogEntry formatted in TextHTTPLogFormatter.
+// HTTPLogger writes lines to its writer as they are, but with other loggers lines are logged as messages of l.
+func (f *TextHTTPLogFormatter) NewLogEntry(l *zerolog.Logger, r *http.Request, ww chi_middleware.WrapResponseWriter) LogEntry {
+	return f.newTextLogEntry(l, r, ww)
+}
+
+// newTextLogEntry implements textWriterFormatter.
+func (f *TextHTTPLogFormatter) newTextLogEntry(w io.Writer, r *http.Request, ww chi_middleware.WrapResponseWriter) LogEntry {
+	le := &TextHTTPLogEntry{f: f, w: w, r: r, ww: ww}
+	if le.requestID = f.cfg.requestID(r.Header.Get(f.cfg.requestIDKey)); le.requestID != "" {
+		ww.Header().Set(f.cfg.requestIDKey, le.requestID)
+	}
+	return le
+}
+
+// TextHTTPLogEntry is the LogEntry formatted in TextHTTPLogFormatter.
+type TextHTTPLogEntry struct {
+	f  *TextHTTPLogFormatter
+	w  io.Writer
+	r  *http.Request
+	ww chi_middleware.WrapResponseWriter
+
+	requestID string
+	panic     bool
+	now       time.Time
+	elapsed   time.Duration
+}
+
+// Add does nothing, since fields can't be written in plain text.
+func (le *TextHTTPLogEntry) Add(f func(e *zerolog.Event)) {}
+
+// AddAttrs does nothing, since fields can't be written in plain text.
+func (le *TextHTTPLogEntry) AddAttrs(attrs ...slog.Attr) {}
+
+// RequestID returns the request ID if WithRequestID is set.
+func (le *TextHTTPLogEntry) RequestID() string {
+	return le.requestID
+}
+
+// Write writes a log line. t is the time the request started, used for $request_time.
+func (le *TextHTTPLogEntry) Write(t time.Time) {
+	cfg := le.f.cfg.forRequest(le.r, "")
+	if cfg.isIgnored(le.r.Method, le.r.URL.Path) {
+		return
+	}
+
+	le.now = le.f.now()
+	le.elapsed = le.now.Sub(t)
+	ok, _ := cfg.sample(SamplingParams{
+		Protocol: "http",
+		Method:   le.r.Method,
+		Path:     le.r.URL.Path,
+		Status:   le.status(),
+		Elapsed:  le.elapsed,
+		TraceID:  traceFromContext(le.r.Context(), le.r.Header.Get).traceID,
+	})
+	if !ok {
+		return
+	}
+
+	var b strings.Builder
+	for _, s := range le.f.segments {
+		if s.v == nil {
+			b.WriteString(s.lit)
+			continue
+		}
+		val := s.v(le)
+		if val == "" {
+			val = "-"
+		}
+		writeTextEscaped(&b, val)
+	}
+	b.WriteByte('\n')
+
+	_, _ = io.WriteString(le.w, b.String())
+}
+
+// RecordPanic implements PanicRecorder. Only the status is reported as 500, since the panic can't be written.
+func (le *TextHTTPLogEntry) RecordPanic(v interface{}, stack []byte) {
+	le.panic = true
+}
+
+// status returns the status of the response, or 500 if a panic is recovered.
+func (le *TextHTTPLogEntry) status() int {
+	if le.panic {
+		return http.StatusInternalServerError
+	}
+	return le.ww.Status()
+}
+
+// requestURI returns the request URI with the redacted query.
+func (le *TextHTTPLogEntry) requestURI() string {
+	if q := le.r.URL.RawQuery; q != "" {
+		return le.r.URL.EscapedPath() + "?" + le.f.cfg.redactor.query(q)
+	}
+	return le.r.URL.EscapedPath()
+}
+
+// writeTextEscaped writes s escaping quotes, backslashes, control characters and bytes from 0x7f as \xHH,
+// like the default escaping of nginx.
+func writeTextEscaped(b *strings.Builder, s string) {
+	const hex = "0123456789ABCDEF"
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c == '"' || c == '\\' || c < 0x20 || c >= 0x7f {
+			b.WriteString(`\x`)
+			b.WriteByte(hex[c>>4])
+			b.WriteByte(hex[c&0xf])
+			continue
+		}
+		b.WriteByte(c)
+	}
+}
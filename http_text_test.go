@@ -0,0 +1,99 @@
+package accesslog
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	chi_middleware "github.com/go-chi/chi/v5/middleware"
+)
+
+func TestTextHTTPLogFormatter(t *testing.T) {
+	start := time.Date(2021, 12, 9, 2, 39, 46, 26696000, time.UTC)
+
+	tests := []struct {
+		name   string
+		format string
+		opts   []httpOption
+		want   string
+	}{
+		{
+			name:   "combined",
+			format: CombinedLogFormat,
+			want:   `192.0.2.1 - alice [09/Dec/2021:11:39:46 +0900] "GET /ping?a=1 HTTP/1.1" 201 2 "-" "curl/7.64.1 \x22x\x22 \xC3\xA9"` + "\n",
+		},
+		{
+			name:   "custom",
+			format: `${request_method}$uri $args $request_time $http_x_request_id $$`,
+			want:   `GET/ping a=1 0.002 rid $$` + "\n",
+		},
+		{
+			name:   "write time",
+			format: `$msec $time_iso8601`,
+			want:   `1639017586.028 2021-12-09T11:39:46+09:00` + "\n",
+		},
+		{
+			name:   "ignored",
+			format: CommonLogFormat,
+			opts:   []httpOption{WithIgnoredPaths(map[string][]string{http.MethodGet: {"/ping"}})},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := NewTextHTTPLogFormatter(tt.format, tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			f.loc = time.FixedZone("KST", 9*60*60)
+			f.now = func() time.Time { return start.Add(1500 * time.Microsecond) }
+
+			// lines are written to the writer of the logger as they are.
+			var buf bytes.Buffer
+			l := NewHTTPLogger(&buf, f)
+
+			r := httptest.NewRequest(http.MethodGet, "/ping?a=1", nil)
+			r.RemoteAddr = "192.0.2.1:1234"
+			r.SetBasicAuth("alice", "secret")
+			r.Header.Set("User-Agent", `curl/7.64.1 "x" é`)
+			r.Header.Set("X-Request-Id", "rid")
+			ww := chi_middleware.NewWrapResponseWriter(httptest.NewRecorder(), 1)
+
+			le := l.NewLogEntry(r, ww)
+			ww.WriteHeader(http.StatusCreated)
+			_, _ = ww.Write([]byte("ok"))
+			le.Write(start)
+
+			if got := buf.String(); got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewTextHTTPLogFormatter_error(t *testing.T) {
+	for _, format := range []string{"$unknown", "${status"} {
+		if _, err := NewTextHTTPLogFormatter(format); err == nil {
+			t.Errorf("NewTextHTTPLogFormatter(%q) must fail", format)
+		}
+	}
+}
+
+func TestHTTPLogger_text(t *testing.T) {
+	f, err := NewTextHTTPLogFormatter(`$request_method $uri $status`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	l := NewHTTPLogger(&buf, f)
+
+	ww := chi_middleware.NewWrapResponseWriter(httptest.NewRecorder(), 1)
+	le := l.NewLogEntry(httptest.NewRequest(http.MethodGet, "/ping", nil), ww)
+	ww.WriteHeader(http.StatusOK)
+	le.Write(time.Now())
+
+	if got, want := buf.String(), "GET /ping 200\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
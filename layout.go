@@ -0,0 +1,77 @@
+package accesslog
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc/codes"
+)
+
+// SchemaVersion is the version of the layout of fields in logs.
+type SchemaVersion int
+
+const (
+	// SchemaLegacy is the original layout, kept as the default not to break existing tables.
+	// "status" is a string, "elapsed(ms)" is the elapsed time in milliseconds, and metadata are JSON encoded strings.
+	SchemaLegacy SchemaVersion = iota + 1
+	// SchemaV2 is the typed layout.
+	// "status" is an integer, i.e. the HTTP status code or the gRPC code with its name in "status_name",
+	// "elapsed_us" is the elapsed time in integer microseconds, metadata are arrays of strings,
+	// and "schema_version" is 2.
+	SchemaV2
+)
+
+// TimeFormat is the format of "time" in logs.
+type TimeFormat int
+
+const (
+	// TimeRFC3339 formats the time in RFC3339 with nanoseconds in UTC, e.g. "2021-12-09T02:39:46.026696Z".
+	TimeRFC3339 TimeFormat = iota
+	// TimeUnixMilli formats the time in integer milliseconds since the Unix epoch.
+	TimeUnixMilli
+)
+
+// v2 reports whether logs are written in SchemaV2.
+func (cfg *commonConfig) v2() bool {
+	return cfg.schemaVersion == SchemaV2
+}
+
+// writeHTTPStatus writes the HTTP status code.
+func (cfg *commonConfig) writeHTTPStatus(f *fields, code int) {
+	if cfg.v2() {
+		f.Int("status", code)
+		return
+	}
+	f.Str("status", strconv.Itoa(code))
+}
+
+// writeGRPCStatus writes the gRPC code.
+func (cfg *commonConfig) writeGRPCStatus(f *fields, code codes.Code) {
+	if cfg.v2() {
+		f.Int("status", int(code)).
+			Str("status_name", code.String())
+		return
+	}
+	f.Str("status", code.String())
+}
+
+// writeTime writes the time the request started and its elapsed time, and the schema version for SchemaV2.
+func (cfg *commonConfig) writeTime(f *fields, t time.Time, elapsed time.Duration) {
+	if cfg.timeFormat == TimeUnixMilli {
+		f.Int64("time", t.UnixNano()/int64(time.Millisecond))
+	} else {
+		f.Str("time", t.UTC().Format(time.RFC3339Nano))
+	}
+
+	if cfg.v2() {
+		f.Elapsed("elapsed_us", elapsed, func(e *zerolog.Event, k string) {
+			e.Int64(k, elapsed.Microseconds())
+		})
+		f.Int("schema_version", int(SchemaV2))
+		return
+	}
+	f.Elapsed("elapsed(ms)", elapsed, func(e *zerolog.Event, k string) {
+		e.Dur(k, elapsed)
+	})
+}
@@ -0,0 +1,66 @@
+package accesslog
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestCommonConfig_layout(t *testing.T) {
+	start := time.Date(2021, 12, 9, 2, 39, 46, 26696000, time.UTC)
+	elapsed := 1500 * time.Microsecond
+
+	tests := []struct {
+		name     string
+		cfg      commonConfig
+		wantHTTP string
+		wantGRPC string
+	}{
+		{
+			name:     "legacy",
+			cfg:      commonConfig{},
+			wantHTTP: `{"status":"404","time":"2021-12-09T02:39:46.026696Z","elapsed(ms)":1.5}` + "\n",
+			wantGRPC: `{"status":"NotFound","md":"[\"a\",\"b\"]"}` + "\n",
+		},
+		{
+			name:     "v2",
+			cfg:      commonConfig{schemaVersion: SchemaV2},
+			wantHTTP: `{"status":404,"time":"2021-12-09T02:39:46.026696Z","elapsed_us":1500,"schema_version":2}` + "\n",
+			wantGRPC: `{"status":5,"status_name":"NotFound","md":["a","b"]}` + "\n",
+		},
+		{
+			name:     "v2 in unix milliseconds",
+			cfg:      commonConfig{schemaVersion: SchemaV2, timeFormat: TimeUnixMilli},
+			wantHTTP: `{"status":404,"time":1639017586026,"elapsed_us":1500,"schema_version":2}` + "\n",
+			wantGRPC: `{"status":5,"status_name":"NotFound","md":["a","b"]}` + "\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			l := zerolog.New(&buf)
+
+			f := tt.cfg.fields(l.Log(), "http")
+			tt.cfg.writeHTTPStatus(f, 404)
+			tt.cfg.writeTime(f, start, elapsed)
+			f.Event().Send()
+			if got := buf.String(); got != tt.wantHTTP {
+				t.Errorf("http = %s, want %s", got, tt.wantHTTP)
+			}
+
+			buf.Reset()
+			cfg := &grpcConfig{commonConfig: tt.cfg, metadata: map[string]string{"x-md": "md"}}
+			f = cfg.fields(l.Log(), "grpc")
+			cfg.writeGRPCStatus(f, codes.NotFound)
+			cfg.writeMetadata(f, metadata.Pairs("x-md", "a", "x-md", "b"))
+			f.Event().Send()
+			if got := buf.String(); got != tt.wantGRPC {
+				t.Errorf("grpc = %s, want %s", got, tt.wantGRPC)
+			}
+		})
+	}
+}
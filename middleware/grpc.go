@@ -5,22 +5,106 @@ import (
 	"time"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/runtime/protoiface"
+	"google.golang.org/protobuf/runtime/protoimpl"
 
 	"github.com/daangn/accesslog"
 )
 
 // UnaryServerInterceptor will write access log to the given grpc server.
-func UnaryServerInterceptor(logger *accesslog.GRPCLogger) grpc.UnaryServerInterceptor {
+func UnaryServerInterceptor(logger *accesslog.GRPCLogger, opts ...option) grpc.UnaryServerInterceptor {
+	cfg := newConfig(opts)
 	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (res interface{}, err error) {
 		le := logger.NewLogEntry(ctx, req, &res, info, &err)
 
 		t := time.Now().UTC()
-		defer func() {
-			le.Write(t)
-		}()
+		defer cfg.recoverGRPC(le, t, &err)
 
 		res, err = handler(accesslog.SetLogEntry(ctx, le), req)
 
 		return
 	}
 }
+
+// StreamServerInterceptor will write access log to the given grpc server for each stream.
+// The log entry is reachable from the context of the stream passed to the handler.
+func StreamServerInterceptor(logger *accesslog.GRPCLogger, opts ...option) grpc.StreamServerInterceptor {
+	cfg := newConfig(opts)
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		stats := new(accesslog.GRPCStreamStats)
+		le := logger.NewStreamLogEntry(ss.Context(), info, stats, &err)
+
+		t := time.Now().UTC()
+		defer cfg.recoverGRPC(le, t, &err)
+
+		err = handler(srv, &serverStream{
+			ServerStream: ss,
+			ctx:          accesslog.SetLogEntry(ss.Context(), le),
+			stats:        stats,
+		})
+
+		return
+	}
+}
+
+// recoverGRPC writes le, recovering a panic if WithRecovery is set. It must be deferred directly to recover.
+// The recovered panic is returned as codes.Internal unless WithRepanic is set.
+func (cfg *config) recoverGRPC(le accesslog.LogEntry, t time.Time, err *error) {
+	if cfg.recovery {
+		if rec := recover(); rec != nil {
+			recordPanic(le, rec)
+			le.Write(t)
+			if cfg.repanic {
+				panic(rec)
+			}
+			*err = status.Error(codes.Internal, "internal error")
+			return
+		}
+	}
+	le.Write(t)
+}
+
+// serverStream is the grpc.ServerStream recording the statistics of messages.
+type serverStream struct {
+	grpc.ServerStream
+	ctx   context.Context
+	stats *accesslog.GRPCStreamStats
+}
+
+// Context returns the context having the log entry of the stream.
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+// SendMsg sends a message and records it.
+func (s *serverStream) SendMsg(m interface{}) error {
+	err := s.ServerStream.SendMsg(m)
+	if err == nil {
+		s.stats.Sent(messageSize(m))
+	}
+	return err
+}
+
+// RecvMsg receives a message and records it.
+func (s *serverStream) RecvMsg(m interface{}) error {
+	err := s.ServerStream.RecvMsg(m)
+	if err == nil {
+		s.stats.Received(messageSize(m))
+	}
+	return err
+}
+
+// messageSize returns the encoded size of m, or -1 if m isn't a proto message.
+// Messages of the legacy API are wrapped as protoadapt.MessageV2Of does.
+func messageSize(m interface{}) int {
+	switch p := m.(type) {
+	case proto.Message:
+		return proto.Size(p)
+	case protoiface.MessageV1:
+		return proto.Size(protoimpl.X.ProtoMessageV2Of(p))
+	}
+	return -1
+}
@@ -0,0 +1,136 @@
+package middleware
+
+import (
+	"context"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/daangn/accesslog"
+)
+
+// UnaryClientInterceptor will write access log of outgoing unary calls.
+func UnaryClientInterceptor(logger *accesslog.GRPCClientLogger) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) (err error) {
+		le := logger.NewLogEntry(ctx, cc.Target(), method, req, reply, &err)
+
+		t := time.Now().UTC()
+		defer func() {
+			le.Write(t)
+		}()
+
+		err = invoker(ctx, method, req, reply, cc, opts...)
+
+		return
+	}
+}
+
+// StreamClientInterceptor will write access log of outgoing streams.
+// The log is written when the stream ends, that is, when RecvMsg returns an error including io.EOF,
+// when the response of a stream that isn't server streaming is received, when SendMsg fails,
+// or when the context of the stream is done before it ends, e.g. the context is canceled or the connection is closed.
+func StreamClientInterceptor(logger *accesslog.GRPCClientLogger) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		cs := &clientStream{
+			stats: new(accesslog.GRPCStreamStats),
+			desc:  desc,
+			t:     time.Now().UTC(),
+			done:  make(chan struct{}),
+		}
+		cs.le = logger.NewStreamLogEntry(ctx, cc.Target(), method, desc, cs.stats, &cs.err)
+
+		s, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			cs.finish(err)
+			return nil, err
+		}
+		cs.ClientStream = s
+		go cs.watch(ctx)
+
+		return cs, nil
+	}
+}
+
+// clientStream is the grpc.ClientStream recording the statistics of messages.
+type clientStream struct {
+	grpc.ClientStream
+	le    accesslog.LogEntry
+	stats *accesslog.GRPCStreamStats
+	desc  *grpc.StreamDesc
+	t     time.Time
+
+	// calls is the number of SendMsg and RecvMsg in progress, which log the end of the stream by themselves.
+	calls int32
+
+	once sync.Once
+	done chan struct{}
+	err  error
+}
+
+// SendMsg sends a message and records it.
+// It writes the log if it fails. io.EOF isn't a failure, since the status is received by RecvMsg.
+func (s *clientStream) SendMsg(m interface{}) error {
+	atomic.AddInt32(&s.calls, 1)
+	defer atomic.AddInt32(&s.calls, -1)
+
+	err := s.ClientStream.SendMsg(m)
+	switch {
+	case err == nil:
+		s.stats.Sent(messageSize(m))
+	case err != io.EOF:
+		s.finish(err)
+	}
+	return err
+}
+
+// RecvMsg receives a message and records it.
+// It writes the log when the stream ends.
+func (s *clientStream) RecvMsg(m interface{}) error {
+	atomic.AddInt32(&s.calls, 1)
+	defer atomic.AddInt32(&s.calls, -1)
+
+	err := s.ClientStream.RecvMsg(m)
+	if err == nil {
+		s.stats.Received(messageSize(m))
+		if !s.desc.ServerStreams {
+			s.finish(nil)
+		}
+		return nil
+	}
+
+	if err == io.EOF {
+		s.finish(nil)
+	} else {
+		s.finish(err)
+	}
+	return err
+}
+
+// watch writes the log when the stream is abandoned, that is, the context of the stream is done before the stream ends.
+// If ctx isn't done, the stream is ended by the connection, or by the server without the status received by RecvMsg.
+// Since its status isn't known, it is logged as codes.Unknown unless SendMsg or RecvMsg in progress logs it.
+func (s *clientStream) watch(ctx context.Context) {
+	select {
+	case <-s.done:
+	case <-s.Context().Done():
+		if err := ctx.Err(); err != nil {
+			s.finish(status.FromContextError(err).Err())
+		} else if atomic.LoadInt32(&s.calls) == 0 {
+			s.finish(status.Error(codes.Unknown, "stream ended without receiving the status"))
+		}
+	}
+}
+
+// finish writes the log once.
+func (s *clientStream) finish(err error) {
+	s.once.Do(func() {
+		s.err = err
+		s.le.Write(s.t)
+		close(s.done)
+	})
+}
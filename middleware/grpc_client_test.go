@@ -0,0 +1,204 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/daangn/accesslog"
+)
+
+// healthServer is the health service for tests.
+// Check fails for the service "unknown", and Watch sends 2 responses, or blocks after 1 for the service "block".
+type healthServer struct {
+	grpc_health_v1.UnimplementedHealthServer
+}
+
+func (healthServer) Check(ctx context.Context, req *grpc_health_v1.HealthCheckRequest) (*grpc_health_v1.HealthCheckResponse, error) {
+	if req.GetService() == "unknown" {
+		return nil, status.Error(codes.NotFound, "unknown service")
+	}
+	return &grpc_health_v1.HealthCheckResponse{Status: grpc_health_v1.HealthCheckResponse_SERVING}, nil
+}
+
+func (healthServer) Watch(req *grpc_health_v1.HealthCheckRequest, ss grpc_health_v1.Health_WatchServer) error {
+	res := &grpc_health_v1.HealthCheckResponse{Status: grpc_health_v1.HealthCheckResponse_SERVING}
+	if err := ss.Send(res); err != nil {
+		return err
+	}
+	if req.GetService() == "block" {
+		<-ss.Context().Done()
+		return status.FromContextError(ss.Context().Err()).Err()
+	}
+	return ss.Send(res)
+}
+
+// dialBufconn serves the health service by the server with sopts over bufconn,
+// and returns the connection to the server dialed with dopts.
+func dialBufconn(t *testing.T, sopts []grpc.ServerOption, dopts ...grpc.DialOption) *grpc.ClientConn {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	s := grpc.NewServer(sopts...)
+	grpc_health_v1.RegisterHealthServer(s, healthServer{})
+	go s.Serve(lis)
+	t.Cleanup(s.Stop)
+
+	dopts = append(dopts,
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	cc, err := grpc.DialContext(context.Background(), "bufnet", dopts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { cc.Close() })
+	return cc
+}
+
+// syncBuffer is the buffer of logs written by other goroutines.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// waitLog waits for a log written into b, and returns it.
+func waitLog(t *testing.T, b *syncBuffer) string {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		if s := b.String(); s != "" {
+			return s
+		}
+	}
+	t.Fatal("no log is written")
+	return ""
+}
+
+func assertLog(t *testing.T, log string, want []string) {
+	t.Helper()
+	if strings.Count(log, "\n") != 1 {
+		t.Errorf("log = %s, want a line", log)
+	}
+	for _, w := range want {
+		if !strings.Contains(log, w) {
+			t.Errorf("log = %s, want to contain %s", log, w)
+		}
+	}
+}
+
+func TestUnaryClientInterceptor(t *testing.T) {
+	tests := []struct {
+		name    string
+		service string
+		want    []string
+	}{
+		{
+			name: "ok",
+			want: []string{`"side":"client"`, `"target":"bufnet"`, `"method":"/grpc.health.v1.Health/Check"`, `"status":"OK"`},
+		},
+		{
+			name:    "error",
+			service: "unknown",
+			want:    []string{`"method":"/grpc.health.v1.Health/Check"`, `"status":"NotFound"`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf syncBuffer
+			l := accesslog.NewGRPCClientLogger(&buf, accesslog.NewDefaultGRPCClientLogFormatter())
+			cc := dialBufconn(t, nil, grpc.WithUnaryInterceptor(UnaryClientInterceptor(l)))
+
+			_, err := grpc_health_v1.NewHealthClient(cc).Check(context.Background(), &grpc_health_v1.HealthCheckRequest{Service: tt.service})
+			if (err != nil) != (tt.service == "unknown") {
+				t.Fatalf("Check() = %v", err)
+			}
+			assertLog(t, buf.String(), tt.want)
+		})
+	}
+}
+
+func TestStreamClientInterceptor(t *testing.T) {
+	t.Run("server streaming", func(t *testing.T) {
+		var buf syncBuffer
+		l := accesslog.NewGRPCClientLogger(&buf, accesslog.NewDefaultGRPCClientLogFormatter())
+		cc := dialBufconn(t, nil, grpc.WithStreamInterceptor(StreamClientInterceptor(l)))
+
+		s, err := grpc_health_v1.NewHealthClient(cc).Watch(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for {
+			if _, err := s.Recv(); err == io.EOF {
+				break
+			} else if err != nil {
+				t.Fatal(err)
+			}
+		}
+		assertLog(t, buf.String(), []string{`"stream":"server_stream"`, `"status":"OK"`, `"msgs_sent":1`, `"msgs_recv":2`})
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		var buf syncBuffer
+		l := accesslog.NewGRPCClientLogger(&buf, accesslog.NewDefaultGRPCClientLogFormatter())
+		cc := dialBufconn(t, nil, grpc.WithStreamInterceptor(StreamClientInterceptor(l)))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		s, err := grpc_health_v1.NewHealthClient(cc).Watch(ctx, &grpc_health_v1.HealthCheckRequest{Service: "block"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := s.Recv(); err != nil {
+			t.Fatal(err)
+		}
+		if got := buf.String(); got != "" {
+			t.Fatalf("log = %s, want none before the stream ends", got)
+		}
+
+		// The stream is abandoned without receiving the status.
+		cancel()
+		assertLog(t, waitLog(t, &buf), []string{`"stream":"server_stream"`, `"status":"Canceled"`, `"msgs_recv":1`})
+	})
+
+	t.Run("connection closed", func(t *testing.T) {
+		var buf syncBuffer
+		l := accesslog.NewGRPCClientLogger(&buf, accesslog.NewDefaultGRPCClientLogFormatter())
+		cc := dialBufconn(t, nil, grpc.WithStreamInterceptor(StreamClientInterceptor(l)))
+
+		s, err := grpc_health_v1.NewHealthClient(cc).Watch(context.Background(), &grpc_health_v1.HealthCheckRequest{Service: "block"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := s.Recv(); err != nil {
+			t.Fatal(err)
+		}
+
+		// The stream ends without the status received by RecvMsg.
+		cc.Close()
+		assertLog(t, waitLog(t, &buf), []string{`"stream":"server_stream"`, `"status":"Unknown"`, `"msgs_recv":1`})
+	})
+}
@@ -0,0 +1,80 @@
+package middleware
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/daangn/accesslog"
+)
+
+func TestStreamServerInterceptor(t *testing.T) {
+	t.Run("ok", func(t *testing.T) {
+		var buf syncBuffer
+		l := accesslog.NewGRPCLogger(&buf, accesslog.NewDefaultGRPCLogFormatter())
+		cc := dialBufconn(t, []grpc.ServerOption{grpc.StreamInterceptor(StreamServerInterceptor(l))})
+
+		s, err := grpc_health_v1.NewHealthClient(cc).Watch(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for {
+			if _, err := s.Recv(); err == io.EOF {
+				break
+			} else if err != nil {
+				t.Fatal(err)
+			}
+		}
+		assertLog(t, waitLog(t, &buf), []string{`"method":"/grpc.health.v1.Health/Watch"`, `"stream":"server_stream"`, `"status":"OK"`, `"msgs_sent":2`, `"msgs_recv":1`})
+	})
+
+	t.Run("error", func(t *testing.T) {
+		var buf syncBuffer
+		l := accesslog.NewGRPCLogger(&buf, accesslog.NewDefaultGRPCLogFormatter())
+		cc := dialBufconn(t, []grpc.ServerOption{grpc.StreamInterceptor(StreamServerInterceptor(l))})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		s, err := grpc_health_v1.NewHealthClient(cc).Watch(ctx, &grpc_health_v1.HealthCheckRequest{Service: "block"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := s.Recv(); err != nil {
+			t.Fatal(err)
+		}
+
+		// The handler returns the error of the canceled stream.
+		cancel()
+		assertLog(t, waitLog(t, &buf), []string{`"stream":"server_stream"`, `"status":"Canceled"`, `"msgs_sent":1`, `"msgs_recv":1`})
+	})
+}
+
+func Test_messageSize(t *testing.T) {
+	req := &grpc_health_v1.HealthCheckRequest{Service: "svc"}
+	tests := []struct {
+		name string
+		m    interface{}
+		want int
+	}{
+		{
+			name: "proto",
+			m:    req,
+			want: proto.Size(req),
+		},
+		{
+			name: "not proto",
+			m:    []byte("svc"),
+			want: -1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := messageSize(tt.m); got != tt.want {
+				t.Errorf("messageSize() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
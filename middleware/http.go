@@ -10,7 +10,8 @@ import (
 )
 
 // AccessLog returns middleware that will log incoming requests.
-func AccessLog(logger *accesslog.HTTPLogger) func(next http.Handler) http.Handler {
+func AccessLog(logger *accesslog.HTTPLogger, opts ...option) func(next http.Handler) http.Handler {
+	cfg := newConfig(opts)
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			ww := chi_middleware.NewWrapResponseWriter(w, r.ProtoMajor)
@@ -18,6 +19,19 @@ func AccessLog(logger *accesslog.HTTPLogger) func(next http.Handler) http.Handle
 
 			t := time.Now().UTC()
 			defer func() {
+				if cfg.recovery {
+					if rec := recover(); rec != nil {
+						recordPanic(entry, rec)
+						entry.Write(t)
+						if cfg.repanic || rec == http.ErrAbortHandler {
+							panic(rec)
+						}
+						if ww.Status() == 0 {
+							ww.WriteHeader(http.StatusInternalServerError)
+						}
+						return
+					}
+				}
 				entry.Write(t)
 			}()
 
@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"runtime/debug"
+
+	"github.com/daangn/accesslog"
+)
+
+type config struct {
+	recovery bool
+	repanic  bool
+}
+
+type option func(cfg *config)
+
+// newConfig returns a new config applied opts.
+func newConfig(opts []option) *config {
+	cfg := new(config)
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return cfg
+}
+
+// WithRecovery specifies that panics of handlers are recovered and logged with "panic" and "stack",
+// reporting the status 500 or codes.Internal.
+func WithRecovery() option {
+	return func(cfg *config) {
+		cfg.recovery = true
+	}
+}
+
+// WithRepanic specifies that panics of handlers are logged like WithRecovery, and then panic again,
+// so that recoverer middleware outside still works.
+func WithRepanic() option {
+	return func(cfg *config) {
+		cfg.recovery = true
+		cfg.repanic = true
+	}
+}
+
+// recordPanic records the panic v on le if it is an accesslog.PanicRecorder.
+func recordPanic(le accesslog.LogEntry, v interface{}) {
+	if pr, ok := le.(accesslog.PanicRecorder); ok {
+		pr.RecordPanic(v, debug.Stack())
+	}
+}
@@ -0,0 +1,90 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/daangn/accesslog"
+)
+
+func TestAccessLog_recovery(t *testing.T) {
+	tests := []struct {
+		name       string
+		opts       []option
+		wantPanic  bool
+		wantStatus int
+	}{
+		{name: "recovery", opts: []option{WithRecovery()}, wantStatus: http.StatusInternalServerError},
+		{name: "repanic", opts: []option{WithRepanic()}, wantPanic: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			h := AccessLog(accesslog.NewHTTPLogger(&buf, accesslog.NewDefaultHTTPLogFormatter()), tt.opts...)(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					panic("boom")
+				}),
+			)
+
+			rec := httptest.NewRecorder()
+			func() {
+				defer func() {
+					if got := recover() != nil; got != tt.wantPanic {
+						t.Errorf("panicked = %v, want %v", got, tt.wantPanic)
+					}
+				}()
+				h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+			}()
+
+			if tt.wantStatus != 0 && rec.Code != tt.wantStatus {
+				t.Errorf("status = %v, want %v", rec.Code, tt.wantStatus)
+			}
+			if got := buf.String(); !strings.Contains(got, `"status":"500"`) || !strings.Contains(got, `"panic":"boom"`) {
+				t.Errorf("log = %s", got)
+			}
+		})
+	}
+}
+
+func TestUnaryServerInterceptor_recovery(t *testing.T) {
+	var buf bytes.Buffer
+	i := UnaryServerInterceptor(accesslog.NewGRPCLogger(&buf, accesslog.NewDefaultGRPCLogFormatter()), WithRecovery())
+
+	_, err := i(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: "/svc/M"}, func(ctx context.Context, req interface{}) (interface{}, error) {
+		panic("boom")
+	})
+	if status.Code(err) != codes.Internal {
+		t.Errorf("err = %v, want Internal", err)
+	}
+	if got := buf.String(); !strings.Contains(got, `"status":"Internal"`) || !strings.Contains(got, `"panic":"boom"`) {
+		t.Errorf("log = %s", got)
+	}
+}
+
+func TestStreamServerInterceptor_recovery(t *testing.T) {
+	var buf bytes.Buffer
+	// the formatter doesn't implement accesslog.GRPCStreamLogFormatter.
+	f := struct{ accesslog.GRPCLogFormatter }{accesslog.NewDefaultGRPCLogFormatter()}
+	i := StreamServerInterceptor(accesslog.NewGRPCLogger(&buf, f), WithRecovery())
+
+	err := i(nil, &serverStream{ctx: context.Background()}, &grpc.StreamServerInfo{FullMethod: "/svc/S"}, func(srv interface{}, ss grpc.ServerStream) error {
+		if accesslog.GetLogEntry(ss.Context()) == nil {
+			t.Error("no log entry in the context")
+		}
+		panic("boom")
+	})
+	if status.Code(err) != codes.Internal {
+		t.Errorf("err = %v, want Internal", err)
+	}
+	if got := buf.String(); got != "" {
+		t.Errorf("log = %s, want none", got)
+	}
+}
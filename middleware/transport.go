@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/daangn/accesslog"
+)
+
+// Transport returns middleware that will log outgoing requests sent by the next http.RoundTripper.
+// If next is nil, http.DefaultTransport is used.
+// The elapsed time is measured until the response headers are received.
+// The entry is stored in the context of the request passed to next, so that next can add fields by accesslog.GetLogEntry.
+func Transport(logger *accesslog.HTTPClientLogger) func(next http.RoundTripper) http.RoundTripper {
+	return func(next http.RoundTripper) http.RoundTripper {
+		if next == nil {
+			next = http.DefaultTransport
+		}
+		return roundTripperFunc(func(r *http.Request) (res *http.Response, err error) {
+			entry := logger.NewLogEntry(r, &res, &err)
+
+			t := time.Now().UTC()
+			defer func() {
+				entry.Write(t)
+			}()
+
+			res, err = next.RoundTrip(RequestWithLogEntry(r, entry))
+
+			return
+		})
+	}
+}
+
+// roundTripperFunc is an adapter to allow the use of ordinary functions as http.RoundTripper.
+type roundTripperFunc func(r *http.Request) (*http.Response, error)
+
+// RoundTrip calls f(r).
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
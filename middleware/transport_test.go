@@ -0,0 +1,72 @@
+package middleware
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+
+	"github.com/daangn/accesslog"
+)
+
+func TestTransport(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+	defer srv.Close()
+	host := strings.TrimPrefix(srv.URL, "http://")
+
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+
+	tests := []struct {
+		name    string
+		url     string
+		wantErr bool
+		want    []string
+	}{
+		{
+			name: "ok",
+			url:  srv.URL + "/a?q=1",
+			want: []string{`"side":"client"`, `"method":"GET"`, `"host":"` + host + `"`, `"path":"/a"`, `"qs":"q=1"`, `"status":"418"`, `"inner":"added"`},
+		},
+		{
+			name:    "error",
+			url:     closed.URL + "/b",
+			wantErr: true,
+			want:    []string{`"path":"/b"`, `"error_kind":"connection_refused"`, `"inner":"added"`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			l := accesslog.NewHTTPClientLogger(&buf, accesslog.NewDefaultHTTPClientLogFormatter())
+			// the inner transport adds a field to the entry in the context of the request.
+			inner := roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+				accesslog.GetLogEntry(r.Context()).Add(func(e *zerolog.Event) {
+					e.Str("inner", "added")
+				})
+				return http.DefaultTransport.RoundTrip(r)
+			})
+			c := &http.Client{Transport: Transport(l)(inner)}
+
+			res, err := c.Get(tt.url)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Get() = %v", err)
+			}
+			if res != nil {
+				res.Body.Close()
+			}
+
+			got := buf.String()
+			for _, w := range tt.want {
+				if !strings.Contains(got, w) {
+					t.Errorf("log = %s, want to contain %s", got, w)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,363 @@
+package accesslog
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// durationFormat is the format of the elapsed time required by a naming.
+type durationFormat int
+
+const (
+	// durationDefault formats the elapsed time by the schema version.
+	durationDefault durationFormat = iota
+	// durationNanos formats the elapsed time in integer nanoseconds.
+	durationNanos
+	// durationString formats the elapsed time in seconds with 9 decimals and the suffix "s", e.g. "0.001500000s".
+	durationString
+)
+
+// FieldNaming is the naming of fields in logs.
+// Names are mapped from the keys of the default layout, e.g. "method", "status", "elapsed(ms)" and "elapsed_us".
+// A name "-" drops the field. The key "url" is the full URL of HTTP requests, written only if it is named.
+type FieldNaming struct {
+	http map[string]string
+	grpc map[string]string
+
+	headerPrefix   string
+	metadataPrefix string
+	nested         bool
+	duration       durationFormat
+}
+
+// CustomNaming returns a FieldNaming renaming fields of both HTTP and gRPC by m.
+// Aliases of WithHeaders and WithMetadata, or headers and metadata without aliases, are renamed by m as well.
+func CustomNaming(m map[string]string) *FieldNaming {
+	return (&FieldNaming{}).Rename(m)
+}
+
+// Rename returns a copy of n renaming fields of both HTTP and gRPC by m in addition, e.g. to customize presets.
+func (n *FieldNaming) Rename(m map[string]string) *FieldNaming {
+	c := *n
+	c.http = mergeNames(n.http, m)
+	c.grpc = mergeNames(n.grpc, m)
+	return &c
+}
+
+// mergeNames returns a new map of names in a overridden by b.
+func mergeNames(a, b map[string]string) map[string]string {
+	m := make(map[string]string, len(a)+len(b))
+	for k, v := range a {
+		m[k] = v
+	}
+	for k, v := range b {
+		m[k] = v
+	}
+	return m
+}
+
+// ECSNaming returns the FieldNaming of Elastic Common Schema.
+// The elapsed time is logged in nanoseconds as "event.duration".
+func ECSNaming() *FieldNaming {
+	common := map[string]string{
+		"protocol":    "network.protocol",
+		"time":        "@timestamp",
+		"elapsed(ms)": "event.duration",
+		"elapsed_us":  "event.duration",
+		"trace_id":    "trace.id",
+		"span_id":     "span.id",
+	}
+	return &FieldNaming{
+		http: mergeNames(common, map[string]string{
+			"path":       "url.path",
+			"qs":         "url.query",
+			"status":     "http.response.status_code",
+			"ua":         "user_agent.original",
+			"method":     "http.request.method",
+			"bytes_in":   "http.request.body.bytes",
+			"bytes_out":  "http.response.body.bytes",
+			"host":       "url.domain",
+			"scheme":     "url.scheme",
+			"client-ip":  "client.ip",
+			"req_body":   "http.request.body.content",
+			"res_body":   "http.response.body.content",
+			"error":      "error.message",
+			"error_kind": "error.type",
+			"request_id": "http.request.id",
+		}),
+		grpc: mergeNames(common, map[string]string{
+			"peer":         "source.address",
+			"error":        "error.message",
+			"error_reason": "error.code",
+			"debug_stack":  "error.stack_trace",
+		}),
+		duration: durationNanos,
+	}
+}
+
+// OTelNaming returns the FieldNaming of the semantic conventions of OpenTelemetry for HTTP and RPC.
+// Headers and metadata without aliases are logged as "http.request.header.<key>" and "rpc.grpc.request.metadata.<key>".
+func OTelNaming() *FieldNaming {
+	return &FieldNaming{
+		http: map[string]string{
+			"protocol":   "network.protocol.name",
+			"path":       "url.path",
+			"qs":         "url.query",
+			"status":     "http.response.status_code",
+			"ua":         "user_agent.original",
+			"method":     "http.request.method",
+			"route":      "http.route",
+			"bytes_in":   "http.request.body.size",
+			"bytes_out":  "http.response.body.size",
+			"host":       "server.address",
+			"scheme":     "url.scheme",
+			"client-ip":  "client.address",
+			"error_kind": "error.type",
+		},
+		grpc: map[string]string{
+			"protocol": "rpc.system",
+			"method":   "rpc.method",
+			"status":   "rpc.grpc.status_code",
+			"peer":     "network.peer.address",
+			"target":   "server.address",
+		},
+		headerPrefix:   "http.request.header.",
+		metadataPrefix: "rpc.grpc.request.metadata.",
+	}
+}
+
+// GCPNaming returns the FieldNaming of the HttpRequest of Cloud Logging, so that HTTP fields are nested in "httpRequest".
+// The full URL is logged as "httpRequest.requestUrl", and the elapsed time as "httpRequest.latency"
+// in the format of google.protobuf.Duration, e.g. "0.001500000s".
+func GCPNaming() *FieldNaming {
+	return &FieldNaming{
+		http: map[string]string{
+			"url":         "httpRequest.requestUrl",
+			"status":      "httpRequest.status",
+			"ua":          "httpRequest.userAgent",
+			"time":        "timestamp",
+			"elapsed(ms)": "httpRequest.latency",
+			"elapsed_us":  "httpRequest.latency",
+			"method":      "httpRequest.requestMethod",
+			"bytes_in":    "httpRequest.requestSize",
+			"bytes_out":   "httpRequest.responseSize",
+			"proto":       "httpRequest.protocol",
+			"client-ip":   "httpRequest.remoteIp",
+		},
+		grpc: map[string]string{
+			"time": "timestamp",
+		},
+		nested:   true,
+		duration: durationString,
+	}
+}
+
+// names returns the names of the protocol.
+func (n *FieldNaming) names(protocol string) map[string]string {
+	if n == nil {
+		return nil
+	}
+	if protocol == "grpc" {
+		return n.grpc
+	}
+	return n.http
+}
+
+// name returns the name of the field key of the protocol. It returns "" if the field is dropped.
+func (n *FieldNaming) name(protocol, key string) string {
+	if v, ok := n.names(protocol)[key]; ok {
+		if v == "-" {
+			return ""
+		}
+		return v
+	}
+	return key
+}
+
+// named reports whether the field key of the protocol is named explicitly and not dropped.
+func (n *FieldNaming) named(protocol, key string) bool {
+	v, ok := n.names(protocol)[key]
+	return ok && v != "-"
+}
+
+// aliasName returns the name of the header or metadata key logged as alias.
+func (n *FieldNaming) aliasName(protocol, key, alias string) string {
+	if alias != "" {
+		return n.name(protocol, alias)
+	}
+	if n != nil {
+		prefix := n.headerPrefix
+		if protocol == "grpc" {
+			prefix = n.metadataPrefix
+		}
+		if prefix != "" {
+			return prefix + strings.ToLower(key)
+		}
+	}
+	return n.name(protocol, key)
+}
+
+// durationFormat returns the format of the elapsed time.
+func (n *FieldNaming) durationFormat() durationFormat {
+	if n == nil {
+		return durationDefault
+	}
+	return n.duration
+}
+
+// fields writes fields into an event with the names given by the naming.
+// If the naming is nested, the names are split at the first dot into an object and the key in it.
+type fields struct {
+	e        *zerolog.Event
+	naming   *FieldNaming
+	protocol string
+
+	groups map[string]*zerolog.Event
+	order  []string
+}
+
+// fields returns a new fields writing into e for the protocol.
+func (cfg *commonConfig) fields(e *zerolog.Event, protocol string) *fields {
+	return &fields{e: e, naming: cfg.naming, protocol: protocol}
+}
+
+// target returns the event and the key to write the named field into. The event is nil if the field is dropped.
+func (f *fields) target(name string) (*zerolog.Event, string) {
+	if name == "" {
+		return nil, ""
+	}
+	if f.naming == nil || !f.naming.nested {
+		return f.e, name
+	}
+	g, k, ok := splitNested(name)
+	if !ok {
+		return f.e, name
+	}
+
+	d, ok := f.groups[g]
+	if !ok {
+		if f.groups == nil {
+			f.groups = map[string]*zerolog.Event{}
+		}
+		d = zerolog.Dict()
+		f.groups[g] = d
+		f.order = append(f.order, g)
+	}
+	return d, k
+}
+
+// splitNested splits the name of a nested naming at the first dot into the object and the key in it.
+func splitNested(name string) (group, key string, ok bool) {
+	i := strings.Index(name, ".")
+	if i <= 0 || i == len(name)-1 {
+		return "", "", false
+	}
+	return name[:i], name[i+1:], true
+}
+
+// field returns the event and the key to write the field key into.
+func (f *fields) field(key string) (*zerolog.Event, string) {
+	return f.target(f.naming.name(f.protocol, key))
+}
+
+// Str writes the string field.
+func (f *fields) Str(key, val string) *fields {
+	if e, k := f.field(key); e != nil {
+		e.Str(k, val)
+	}
+	return f
+}
+
+// Strs writes the field of strings.
+func (f *fields) Strs(key string, vals []string) *fields {
+	if e, k := f.field(key); e != nil {
+		e.Strs(k, vals)
+	}
+	return f
+}
+
+// Int writes the int field.
+func (f *fields) Int(key string, val int) *fields {
+	if e, k := f.field(key); e != nil {
+		e.Int(k, val)
+	}
+	return f
+}
+
+// Int64 writes the int64 field.
+func (f *fields) Int64(key string, val int64) *fields {
+	if e, k := f.field(key); e != nil {
+		e.Int64(k, val)
+	}
+	return f
+}
+
+// Float64 writes the float64 field.
+func (f *fields) Float64(key string, val float64) *fields {
+	if e, k := f.field(key); e != nil {
+		e.Float64(k, val)
+	}
+	return f
+}
+
+// Bool writes the bool field.
+func (f *fields) Bool(key string, val bool) *fields {
+	if e, k := f.field(key); e != nil {
+		e.Bool(k, val)
+	}
+	return f
+}
+
+// RawJSON writes the field of raw JSON.
+func (f *fields) RawJSON(key string, val []byte) *fields {
+	if e, k := f.field(key); e != nil {
+		e.RawJSON(k, val)
+	}
+	return f
+}
+
+// Alias writes the string field of the header or metadata key logged as alias.
+func (f *fields) Alias(key, alias, val string) *fields {
+	if e, k := f.target(f.naming.aliasName(f.protocol, key, alias)); e != nil {
+		e.Str(k, val)
+	}
+	return f
+}
+
+// AliasStrs writes the field of strings of the header or metadata key logged as alias.
+func (f *fields) AliasStrs(key, alias string, vals []string) *fields {
+	if e, k := f.target(f.naming.aliasName(f.protocol, key, alias)); e != nil {
+		e.Strs(k, vals)
+	}
+	return f
+}
+
+// Elapsed writes the elapsed time in the format of the naming, or by fn of the schema version by default.
+func (f *fields) Elapsed(key string, d time.Duration, fn func(e *zerolog.Event, k string)) *fields {
+	e, k := f.field(key)
+	if e == nil {
+		return f
+	}
+	switch f.naming.durationFormat() {
+	case durationNanos:
+		e.Int64(k, d.Nanoseconds())
+	case durationString:
+		e.Str(k, strconv.FormatFloat(d.Seconds(), 'f', 9, 64)+"s")
+	default:
+		fn(e, k)
+	}
+	return f
+}
+
+// Event attaches the nested objects to the event, and returns the event.
+// Fields must not be written after calling Event.
+func (f *fields) Event() *zerolog.Event {
+	for _, g := range f.order {
+		f.e.Dict(g, f.groups[g])
+	}
+	f.groups, f.order = nil, nil
+	return f.e
+}
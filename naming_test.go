@@ -0,0 +1,188 @@
+package accesslog
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+func TestFields(t *testing.T) {
+	start := time.Date(2021, 12, 9, 2, 39, 46, 26696000, time.UTC)
+	elapsed := 1500 * time.Microsecond
+
+	tests := []struct {
+		name string
+		cfg  httpConfig
+		want string
+	}{
+		{
+			name: "default",
+			cfg:  httpConfig{headers: map[string]string{"x-request-id": "rid"}},
+			want: `{"path":"/","status":"200","time":"2021-12-09T02:39:46.026696Z","elapsed(ms)":1.5,"rid":"id"}` + "\n",
+		},
+		{
+			name: "ecs",
+			cfg:  httpConfig{commonConfig: commonConfig{naming: ECSNaming(), schemaVersion: SchemaV2}},
+			want: `{"url.path":"/","http.response.status_code":200,"@timestamp":"2021-12-09T02:39:46.026696Z","event.duration":1500000,"schema_version":2}` + "\n",
+		},
+		{
+			name: "otel",
+			cfg:  httpConfig{commonConfig: commonConfig{naming: OTelNaming()}, headers: map[string]string{"X-Request-Id": ""}},
+			want: `{"url.path":"/","http.response.status_code":"200","time":"2021-12-09T02:39:46.026696Z","elapsed(ms)":1.5,"http.request.header.x-request-id":"id"}` + "\n",
+		},
+		{
+			name: "gcp",
+			cfg:  httpConfig{commonConfig: commonConfig{naming: GCPNaming()}},
+			want: `{"path":"/","timestamp":"2021-12-09T02:39:46.026696Z","httpRequest":{"status":"200","latency":"0.001500000s"}}` + "\n",
+		},
+		{
+			name: "custom",
+			cfg: httpConfig{
+				commonConfig: commonConfig{naming: CustomNaming(map[string]string{"path": "uri", "time": "-", "rid": "request_id"})},
+				headers:      map[string]string{"x-request-id": "rid"},
+			},
+			want: `{"uri":"/","status":"200","elapsed(ms)":1.5,"request_id":"id"}` + "\n",
+		},
+		{
+			name: "preset renamed",
+			cfg:  httpConfig{commonConfig: commonConfig{naming: ECSNaming().Rename(map[string]string{"url.path": "-", "path": "url.original"})}},
+			want: `{"url.original":"/","http.response.status_code":"200","@timestamp":"2021-12-09T02:39:46.026696Z","event.duration":1500000}` + "\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			l := zerolog.New(&buf)
+
+			f := tt.cfg.fields(l.Log(), "http").Str("path", "/")
+			tt.cfg.writeHTTPStatus(f, 200)
+			tt.cfg.writeTime(f, start, elapsed)
+			tt.cfg.writeHeaders(f, map[string][]string{"X-Request-Id": {"id"}})
+			f.Event().Send()
+			if got := buf.String(); got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHTTPSchema_naming(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []httpOption
+		want []string
+	}{
+		{
+			name: "ecs",
+			opts: []httpOption{WithFieldNaming(ECSNaming()), WithMethod()},
+			want: []string{"network.protocol", "url.path", "http.response.status_code", "user_agent.original", "@timestamp", "event.duration", "url.query", "http.request.method", "panic", "stack"},
+		},
+		{
+			name: "otel",
+			opts: []httpOption{WithFieldNaming(OTelNaming()), WithHeaders("x-request-id", "x-b3-traceid:trace_id")},
+			want: []string{"network.protocol.name", "url.path", "http.response.status_code", "user_agent.original", "time", "elapsed(ms)", "url.query", "http.request.header.x-request-id", "trace_id", "panic", "stack"},
+		},
+		{
+			name: "custom",
+			opts: []httpOption{WithFieldNaming(CustomNaming(map[string]string{"ua": "-", "rid": "request_id"})), WithHeaders("x-request-id:rid")},
+			want: []string{"protocol", "path", "status", "time", "elapsed(ms)", "qs", "request_id", "panic", "stack"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := columnNames(HTTPSchema(NewDefaultHTTPLogFormatter(tt.opts...)).Columns)
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("HTTPSchema() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGRPCSchema_naming(t *testing.T) {
+	s := GRPCSchema(NewDefaultGRPCLogFormatter(WithFieldNaming(GCPNaming())))
+	for _, c := range s.Columns {
+		if c.Name == "elapsed(ms)" && c.Type != StringColumn {
+			t.Errorf("type of %s = %v, want %v", c.Name, c.Type, StringColumn)
+		}
+	}
+}
+
+func TestHTTPConfig_writeURL(t *testing.T) {
+	r := httptest.NewRequest("GET", "http://example.com/a%2Fb?token=x&q=1", nil)
+	r.Header.Set("X-Forwarded-Proto", "https")
+
+	var buf bytes.Buffer
+	l := zerolog.New(&buf)
+	cfg := httpConfig{commonConfig: commonConfig{naming: GCPNaming()}}
+	f := cfg.fields(l.Log(), "http")
+	cfg.writeURL(f, r)
+	f.Event().Send()
+
+	want := `{"httpRequest":{"requestUrl":"https://example.com/a%2Fb?token=x&q=1"}}` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+
+	buf.Reset()
+	cfg = httpConfig{}
+	f = cfg.fields(l.Log(), "http")
+	cfg.writeURL(f, r)
+	f.Event().Send()
+	if got := buf.String(); got != "{}\n" {
+		t.Errorf("got %s, want the URL not written without named", got)
+	}
+}
+
+func TestHTTPSchema_nested(t *testing.T) {
+	s := HTTPSchema(NewDefaultHTTPLogFormatter(WithFieldNaming(GCPNaming()), WithMethod()))
+
+	var req *Column
+	for i, c := range s.Columns {
+		if strings.Contains(c.Name, ".") {
+			t.Errorf("column %s must be nested", c.Name)
+		}
+		if c.Name == "httpRequest" {
+			req = &s.Columns[i]
+		}
+	}
+	if req == nil || req.Type != StructColumn {
+		t.Fatalf("httpRequest = %+v, want StructColumn", req)
+	}
+	want := []string{"status", "userAgent", "latency", "requestUrl", "requestMethod"}
+	if got := columnNames(req.Fields); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("fields of httpRequest = %v, want %v", got, want)
+	}
+
+	b, err := s.JSONSchema()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var js struct {
+		Properties map[string]struct {
+			Type       string                     `json:"type"`
+			Properties map[string]json.RawMessage `json:"properties"`
+		} `json:"properties"`
+	}
+	if err := json.Unmarshal(b, &js); err != nil {
+		t.Fatal(err)
+	}
+	if p := js.Properties["httpRequest"]; p.Type != "object" || p.Properties["requestUrl"] == nil {
+		t.Errorf("httpRequest = %+v, want object with requestUrl", p)
+	}
+
+	if ddl := s.AthenaDDL("t", "s3://b/", JSONStorage); !strings.Contains(ddl, "`httprequest` struct<status:string,useragent:string,latency:string,requesturl:string,requestmethod:string>") {
+		t.Errorf("AthenaDDL() = %s", ddl)
+	}
+	bq, err := s.BigQuery()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(bq), `"type": "RECORD"`) || !strings.Contains(string(bq), `"name": "requesturl"`) {
+		t.Errorf("BigQuery() = %s", bq)
+	}
+}
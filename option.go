@@ -0,0 +1,110 @@
+package accesslog
+
+// Option is the option for both HTTP and gRPC formatters.
+type Option interface {
+	httpOption
+	grpcOption
+}
+
+// commonConfig is the configuration shared by httpConfig and grpcConfig.
+type commonConfig struct {
+	redactor      *Redactor
+	sampler       Sampler
+	schemaVersion SchemaVersion
+	timeFormat    TimeFormat
+	naming        *FieldNaming
+	withTrace     bool
+	requestIDKey  string
+}
+
+type commonOption func(cfg *commonConfig)
+
+func (f commonOption) applyHTTP(cfg *httpConfig) {
+	f(&cfg.commonConfig)
+}
+
+func (f commonOption) applyGRPC(cfg *grpcConfig) {
+	f(&cfg.commonConfig)
+}
+
+// WithRedactor specifies the redactor to redact sensitive values in headers, metadata, query strings and bodies.
+func WithRedactor(r *Redactor) Option {
+	return commonOption(func(cfg *commonConfig) {
+		cfg.redactor = r
+	})
+}
+
+// WithSampler specifies the sampler deciding whether a log entry should be written.
+// Sampled entries are logged with "sample_rate" if the rate is less than 1.
+func WithSampler(s Sampler) Option {
+	return commonOption(func(cfg *commonConfig) {
+		cfg.sampler = s
+	})
+}
+
+// WithSchemaVersion specifies the layout of fields in logs. The default is SchemaLegacy.
+func WithSchemaVersion(v SchemaVersion) Option {
+	return commonOption(func(cfg *commonConfig) {
+		cfg.schemaVersion = v
+	})
+}
+
+// WithTimeFormat specifies the format of "time" in logs. The default is TimeRFC3339.
+func WithTimeFormat(f TimeFormat) Option {
+	return commonOption(func(cfg *commonConfig) {
+		cfg.timeFormat = f
+	})
+}
+
+// WithFieldNaming specifies the naming of fields, e.g. ECSNaming, OTelNaming, GCPNaming or CustomNaming.
+// Fields added by LogEntry.Add aren't renamed.
+func WithFieldNaming(n *FieldNaming) Option {
+	return commonOption(func(cfg *commonConfig) {
+		cfg.naming = n
+	})
+}
+
+// WithTrace specifies whether "trace_id", "span_id" and "trace_flags" should be logged to join logs to traces.
+// They are taken from the OpenTelemetry span in the context of the request, or, failing that,
+// the W3C traceparent or B3 headers and metadata propagated with the request.
+func WithTrace() Option {
+	return commonOption(func(cfg *commonConfig) {
+		cfg.withTrace = true
+	})
+}
+
+// WithRequestID specifies the header or metadata key of the request ID, X-Request-Id if key is empty.
+// The incoming request ID, or a UUIDv7 generated if absent, is set to the header of the response,
+// logged as "request_id" and returned by GetRequestID. Clients log the request ID of the context if any.
+func WithRequestID(key string) Option {
+	if key == "" {
+		key = defaultRequestIDKey
+	}
+	return commonOption(func(cfg *commonConfig) {
+		cfg.requestIDKey = key
+	})
+}
+
+// Toggle is whether a rule turns an option on or off, or inherits the one of the formatter.
+type Toggle int
+
+const (
+	// ToggleInherit inherits the option of the formatter.
+	ToggleInherit Toggle = iota
+	// ToggleOn turns the option on.
+	ToggleOn
+	// ToggleOff turns the option off.
+	ToggleOff
+)
+
+// apply returns on overridden by the toggle.
+func (t Toggle) apply(on bool) bool {
+	switch t {
+	case ToggleOn:
+		return true
+	case ToggleOff:
+		return false
+	default:
+		return on
+	}
+}
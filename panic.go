@@ -0,0 +1,55 @@
+package accesslog
+
+import (
+	"bytes"
+	"fmt"
+)
+
+const maxPanicStackFrames = 32
+
+// PanicRecorder is implemented by LogEntry recording a panic recovered by middleware.
+// The entry is logged with "panic" and "stack", and the status is reported as 500 or codes.Internal.
+type PanicRecorder interface {
+	RecordPanic(v interface{}, stack []byte)
+}
+
+// panicInfo is a panic recovered while handling a request.
+type panicInfo struct {
+	value string
+	stack string
+}
+
+// newPanicInfo returns a new panicInfo of v and the stack trimmed by trimStack.
+func newPanicInfo(v interface{}, stack []byte) *panicInfo {
+	return &panicInfo{value: fmt.Sprint(v), stack: trimStack(stack)}
+}
+
+// trimStack trims the stack from debug.Stack to the frames from where the panic occurred,
+// dropping the goroutine header and the frames of recovering, up to maxPanicStackFrames.
+func trimStack(stack []byte) string {
+	lines := bytes.Split(bytes.TrimSpace(stack), []byte{'\n'})
+	if len(lines) != 0 && bytes.HasPrefix(lines[0], []byte("goroutine ")) {
+		lines = lines[1:]
+	}
+	for i := 0; i+1 < len(lines); i += 2 {
+		if bytes.HasPrefix(lines[i], []byte("panic(")) {
+			lines = lines[i+2:]
+			break
+		}
+	}
+	if len(lines) > maxPanicStackFrames*2 {
+		lines = lines[:maxPanicStackFrames*2]
+	}
+	for i := range lines {
+		lines[i] = bytes.TrimSpace(lines[i])
+	}
+	return string(bytes.Join(lines, []byte{'\n'}))
+}
+
+// writePanic writes the recovered panic if any.
+func writePanic(f *fields, p *panicInfo) {
+	if p != nil {
+		f.Str("panic", p.value).
+			Str("stack", p.stack)
+	}
+}
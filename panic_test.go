@@ -0,0 +1,55 @@
+package accesslog
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"runtime/debug"
+	"strings"
+	"testing"
+	"time"
+
+	chi_middleware "github.com/go-chi/chi/v5/middleware"
+	"github.com/rs/zerolog"
+)
+
+func Test_trimStack(t *testing.T) {
+	var stack []byte
+	func() {
+		defer func() {
+			if recover() != nil {
+				stack = debug.Stack()
+			}
+		}()
+		panicForTest()
+	}()
+
+	got := trimStack(stack)
+	if !strings.HasPrefix(got, "github.com/daangn/accesslog.panicForTest(") {
+		t.Errorf("trimStack() = %s", got)
+	}
+	if strings.Contains(got, "runtime/debug.Stack") || strings.HasPrefix(got, "goroutine ") {
+		t.Errorf("trimStack() must drop the frames of recovering: %s", got)
+	}
+}
+
+func panicForTest() {
+	panic("boom")
+}
+
+func TestDefaultHTTPLogEntry_RecordPanic(t *testing.T) {
+	var buf bytes.Buffer
+	l := zerolog.New(&buf)
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	le := NewDefaultHTTPLogFormatter().NewLogEntry(&l, r, chi_middleware.NewWrapResponseWriter(httptest.NewRecorder(), 1))
+
+	le.(PanicRecorder).RecordPanic("boom", []byte("goroutine 1 [running]:\nmain.f()\n\t/main.go:1"))
+	le.Write(time.Now())
+
+	got := buf.String()
+	for _, want := range []string{`"status":"500"`, `"panic":"boom"`, `"stack":"main.f()\n/main.go:1"`} {
+		if !strings.Contains(got, want) {
+			t.Errorf("got %s, want %s", got, want)
+		}
+	}
+}
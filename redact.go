@@ -0,0 +1,316 @@
+package accesslog
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// RedactMode is the mode how a sensitive value is redacted.
+// The zero value is treated as RedactMask, so that an unset mode never leaks or drops values.
+type RedactMode int
+
+const (
+	// RedactMask replaces the value with the fixed mask.
+	RedactMask RedactMode = iota + 1
+	// RedactPartial masks the value except the last 4 characters.
+	RedactPartial
+	// RedactHash replaces the value with the hex encoded HMAC-SHA256 of the value keyed by RedactHashKey.
+	// The value is masked instead if the key isn't specified, since an unkeyed hash of a low-entropy value
+	// such as an email can be brute-forced.
+	RedactHash
+	// RedactDrop drops the value from the log.
+	RedactDrop
+)
+
+const (
+	defaultRedactMask = "***"
+	partialVisible    = 4
+)
+
+// Redactor redacts sensitive values in headers, metadata, query strings, JSON bodies and proto messages.
+// A nil Redactor doesn't redact anything.
+type Redactor struct {
+	mask         string
+	hashKey      []byte
+	headers      map[string]RedactMode
+	queries      map[string]RedactMode
+	jsonPaths    []jsonPathRule
+	protoFields  map[string]RedactMode
+	protoOptions []protoOptionRule
+}
+
+type jsonPathRule struct {
+	segs []string
+	mode RedactMode
+}
+
+type protoOptionRule struct {
+	xt   protoreflect.ExtensionType
+	mode RedactMode
+}
+
+// NewRedactor returns a new Redactor.
+func NewRedactor(opts ...redactOption) *Redactor {
+	r := &Redactor{
+		mask:        defaultRedactMask,
+		headers:     map[string]RedactMode{},
+		queries:     map[string]RedactMode{},
+		protoFields: map[string]RedactMode{},
+	}
+	for _, fn := range opts {
+		fn(r)
+	}
+	return r
+}
+
+// value returns the redacted s by mode.
+func (r *Redactor) value(s string, mode RedactMode) string {
+	switch mode {
+	case RedactPartial:
+		n := utf8.RuneCountInString(s)
+		if n <= partialVisible {
+			return strings.Repeat("*", n)
+		}
+		rs := []rune(s)
+		return strings.Repeat("*", n-partialVisible) + string(rs[n-partialVisible:])
+	case RedactHash:
+		if len(r.hashKey) == 0 {
+			return r.mask
+		}
+		h := hmac.New(sha256.New, r.hashKey)
+		h.Write([]byte(s))
+		return hex.EncodeToString(h.Sum(nil))
+	default:
+		return r.mask
+	}
+}
+
+// header returns the redacted values of the header or metadata name.
+// It returns false if the values should be dropped.
+func (r *Redactor) header(name string, vals []string) ([]string, bool) {
+	if r == nil {
+		return vals, true
+	}
+	mode, ok := r.headers[strings.ToLower(name)]
+	if !ok {
+		return vals, true
+	}
+	if mode == RedactDrop {
+		return nil, false
+	}
+	rvs := make([]string, len(vals))
+	for i, v := range vals {
+		rvs[i] = r.value(v, mode)
+	}
+	return rvs, true
+}
+
+// query returns the redacted query string or form body.
+// The parameters are rewritten in place to keep their order, and the mask is kept literal.
+func (r *Redactor) query(raw string) string {
+	if r == nil || len(r.queries) == 0 || raw == "" {
+		return raw
+	}
+
+	var b strings.Builder
+	redacted := false
+	for _, kv := range strings.Split(raw, "&") {
+		k, v := kv, ""
+		if i := strings.IndexByte(kv, '='); i != -1 {
+			k, v = kv[:i], kv[i+1:]
+		}
+		name, err := url.QueryUnescape(k)
+		mode, ok := r.queries[name]
+		if err != nil || !ok {
+			writeQueryParam(&b, kv)
+			continue
+		}
+		redacted = true
+		if mode == RedactDrop {
+			continue
+		}
+		if uv, err := url.QueryUnescape(v); err == nil {
+			v = uv
+		}
+		writeQueryParam(&b, k+"="+queryEscape(r.value(v, mode)))
+	}
+	if !redacted {
+		return raw
+	}
+	return b.String()
+}
+
+// writeQueryParam writes the parameter kv into b separated by "&".
+func writeQueryParam(b *strings.Builder, kv string) {
+	if b.Len() != 0 {
+		b.WriteByte('&')
+	}
+	b.WriteString(kv)
+}
+
+// queryEscape escapes s as a value of query strings, keeping "*" of masks literal.
+func queryEscape(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "%2A", "*")
+}
+
+// json returns the redacted JSON. It returns false if b can't be redacted as JSON.
+func (r *Redactor) json(b []byte) ([]byte, bool) {
+	if r == nil || len(r.jsonPaths) == 0 {
+		return b, true
+	}
+
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+	var v interface{}
+	if err := d.Decode(&v); err != nil {
+		return nil, false
+	}
+	for _, rule := range r.jsonPaths {
+		r.redactJSON(v, rule.segs, rule.mode)
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, false
+	}
+	return bytes.TrimRight(buf.Bytes(), "\n"), true
+}
+
+// redactJSON redacts the values in v matched by segs. Arrays are traversed transparently,
+// "*" matches any key and "**" matches zero or more levels.
+func (r *Redactor) redactJSON(v interface{}, segs []string, mode RedactMode) {
+	if len(segs) == 0 {
+		return
+	}
+	switch n := v.(type) {
+	case []interface{}:
+		for _, e := range n {
+			r.redactJSON(e, segs, mode)
+		}
+	case map[string]interface{}:
+		if segs[0] == "**" {
+			r.redactJSON(n, segs[1:], mode)
+			for _, c := range n {
+				r.redactJSON(c, segs, mode)
+			}
+			return
+		}
+		for k, c := range n {
+			if segs[0] != "*" && segs[0] != k {
+				continue
+			}
+			if len(segs) > 1 {
+				r.redactJSON(c, segs[1:], mode)
+			} else if mode == RedactDrop {
+				delete(n, k)
+			} else {
+				n[k] = r.jsonValue(c, mode)
+			}
+		}
+	}
+}
+
+// jsonValue returns the redacted JSON value.
+func (r *Redactor) jsonValue(v interface{}, mode RedactMode) interface{} {
+	switch n := v.(type) {
+	case string:
+		return r.value(n, mode)
+	case json.Number:
+		return r.value(n.String(), mode)
+	default:
+		b, _ := json.Marshal(n)
+		return r.value(string(b), mode)
+	}
+}
+
+// proto returns the redacted clone of m. It returns m itself if there is nothing to redact.
+func (r *Redactor) proto(m proto.Message) proto.Message {
+	if r == nil || (len(r.protoFields) == 0 && len(r.protoOptions) == 0) || m == nil {
+		return m
+	}
+	c := proto.Clone(m)
+	r.redactProto(c.ProtoReflect())
+	return c
+}
+
+// redactProto redacts the fields of m recursively.
+func (r *Redactor) redactProto(m protoreflect.Message) {
+	m.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		if mode, ok := r.protoFieldMode(fd); ok {
+			r.redactProtoField(m, fd, v, mode)
+			return true
+		}
+
+		switch {
+		case fd.IsList() && fd.Message() != nil:
+			l := v.List()
+			for i := 0; i < l.Len(); i++ {
+				r.redactProto(l.Get(i).Message())
+			}
+		case fd.IsMap() && fd.MapValue().Message() != nil:
+			v.Map().Range(func(_ protoreflect.MapKey, mv protoreflect.Value) bool {
+				r.redactProto(mv.Message())
+				return true
+			})
+		case !fd.IsList() && !fd.IsMap() && fd.Message() != nil:
+			r.redactProto(v.Message())
+		}
+		return true
+	})
+}
+
+// protoFieldMode returns the mode for fd if it should be redacted.
+func (r *Redactor) protoFieldMode(fd protoreflect.FieldDescriptor) (RedactMode, bool) {
+	for _, n := range []string{string(fd.Name()), fd.JSONName(), string(fd.FullName())} {
+		if mode, ok := r.protoFields[n]; ok {
+			return mode, true
+		}
+	}
+	for _, rule := range r.protoOptions {
+		opts := fd.Options()
+		if opts == nil || !proto.HasExtension(opts, rule.xt) {
+			continue
+		}
+		if b, ok := proto.GetExtension(opts, rule.xt).(bool); ok && !b {
+			continue
+		}
+		return rule.mode, true
+	}
+	return 0, false
+}
+
+// redactProtoField redacts the field fd of m. Only string and bytes fields can be masked or hashed,
+// and the other fields are cleared.
+func (r *Redactor) redactProtoField(m protoreflect.Message, fd protoreflect.FieldDescriptor, v protoreflect.Value, mode RedactMode) {
+	if mode == RedactDrop || fd.IsMap() || (fd.Kind() != protoreflect.StringKind && fd.Kind() != protoreflect.BytesKind) {
+		m.Clear(fd)
+		return
+	}
+
+	redact := func(v protoreflect.Value) protoreflect.Value {
+		if fd.Kind() == protoreflect.BytesKind {
+			return protoreflect.ValueOfBytes([]byte(r.value(string(v.Bytes()), mode)))
+		}
+		return protoreflect.ValueOfString(r.value(v.String(), mode))
+	}
+
+	if fd.IsList() {
+		l := v.List()
+		for i := 0; i < l.Len(); i++ {
+			l.Set(i, redact(l.Get(i)))
+		}
+		return
+	}
+	m.Set(fd, redact(v))
+}
@@ -0,0 +1,74 @@
+package accesslog
+
+import (
+	"strings"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+type redactOption func(r *Redactor)
+
+// RedactHeaders specifies HTTP headers and gRPC metadata to be redacted by mode. Names are case-insensitive.
+// e.g. RedactHeaders(RedactMask, "authorization", "cookie")
+func RedactHeaders(mode RedactMode, names ...string) redactOption {
+	return func(r *Redactor) {
+		for _, n := range names {
+			r.headers[strings.ToLower(n)] = mode
+		}
+	}
+}
+
+// RedactQueries specifies parameters of query strings and form bodies to be redacted by mode.
+func RedactQueries(mode RedactMode, names ...string) redactOption {
+	return func(r *Redactor) {
+		for _, n := range names {
+			r.queries[n] = mode
+		}
+	}
+}
+
+// RedactJSONPaths specifies paths in JSON bodies and messages to be redacted by mode.
+// A path is keys separated by dots, optionally prefixed with "$.". Arrays are traversed transparently,
+// "*" matches any key and "**" matches zero or more levels.
+// e.g. "user.password", "cards.*.number", "**.token"
+func RedactJSONPaths(mode RedactMode, paths ...string) redactOption {
+	return func(r *Redactor) {
+		for _, p := range paths {
+			p = strings.TrimPrefix(p, "$.")
+			r.jsonPaths = append(r.jsonPaths, jsonPathRule{segs: strings.Split(p, "."), mode: mode})
+		}
+	}
+}
+
+// RedactProtoFields specifies fields of proto messages to be redacted by mode.
+// A field can be specified by its name, JSON name or full name. e.g. "card_number", "cardNumber", "payments.v1.Card.number"
+// Fields other than string and bytes are cleared unless mode is RedactDrop.
+func RedactProtoFields(mode RedactMode, names ...string) redactOption {
+	return func(r *Redactor) {
+		for _, n := range names {
+			r.protoFields[n] = mode
+		}
+	}
+}
+
+// RedactProtoFieldOption specifies fields of proto messages having the custom field option xt to be redacted by mode.
+// If xt is a bool option, only fields with the option set to true are redacted.
+func RedactProtoFieldOption(mode RedactMode, xt protoreflect.ExtensionType) redactOption {
+	return func(r *Redactor) {
+		r.protoOptions = append(r.protoOptions, protoOptionRule{xt: xt, mode: mode})
+	}
+}
+
+// RedactMaskString specifies the fixed mask used by RedactMask. The default is "***".
+func RedactMaskString(mask string) redactOption {
+	return func(r *Redactor) {
+		r.mask = mask
+	}
+}
+
+// RedactHashKey specifies the key of HMAC used by RedactHash. RedactHash masks values without the key.
+func RedactHashKey(key []byte) redactOption {
+	return func(r *Redactor) {
+		r.hashKey = key
+	}
+}
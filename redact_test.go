@@ -0,0 +1,245 @@
+package accesslog
+
+import (
+	"reflect"
+	"testing"
+
+	pb "google.golang.org/grpc/examples/helloworld/helloworld"
+)
+
+func TestRedactor_value(t *testing.T) {
+	r := NewRedactor(RedactHashKey([]byte("key")))
+	tests := []struct {
+		name string
+		r    *Redactor
+		s    string
+		mode RedactMode
+		want string
+	}{
+		{
+			name: "mask",
+			s:    "secret",
+			mode: RedactMask,
+			want: "***",
+		},
+		{
+			name: "partial",
+			s:    "4111111111111111",
+			mode: RedactPartial,
+			want: "************1111",
+		},
+		{
+			name: "partial short",
+			s:    "abc",
+			mode: RedactPartial,
+			want: "***",
+		},
+		{
+			name: "hash",
+			s:    "secret",
+			mode: RedactHash,
+			want: "25cf3c44c8f39313e8cbf7c23e22fe8b2ee8b288ee5206b0a6397583a1f7f0ef",
+		},
+		{
+			name: "hash without key",
+			r:    NewRedactor(),
+			s:    "secret",
+			mode: RedactHash,
+			want: "***",
+		},
+		{
+			name: "zero",
+			s:    "secret",
+			want: "***",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.r == nil {
+				tt.r = r
+			}
+			if got := tt.r.value(tt.s, tt.mode); got != tt.want {
+				t.Errorf("value() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedactor_header(t *testing.T) {
+	r := NewRedactor(
+		RedactHeaders(RedactMask, "Authorization"),
+		RedactHeaders(RedactDrop, "cookie"),
+	)
+	tests := []struct {
+		name   string
+		header string
+		vals   []string
+		want   []string
+		wantOK bool
+	}{
+		{
+			name:   "masked",
+			header: "authorization",
+			vals:   []string{"Bearer token"},
+			want:   []string{"***"},
+			wantOK: true,
+		},
+		{
+			name:   "dropped",
+			header: "Cookie",
+			vals:   []string{"a=b"},
+			wantOK: false,
+		},
+		{
+			name:   "not redacted",
+			header: "user-agent",
+			vals:   []string{"curl"},
+			want:   []string{"curl"},
+			wantOK: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := r.header(tt.header, tt.vals)
+			if ok != tt.wantOK || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("header() = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestRedactor_query(t *testing.T) {
+	r := NewRedactor(
+		RedactQueries(RedactMask, "token"),
+		RedactQueries(RedactDrop, "password"),
+		RedactQueries(RedactPartial, "card"),
+	)
+	tests := []struct {
+		name string
+		raw  string
+		want string
+	}{
+		{
+			name: "redacted",
+			raw:  "token=abc&password=def&q=1",
+			want: "token=***&q=1",
+		},
+		{
+			name: "escaped",
+			raw:  "q=a+b&card=12%2634567&token=x",
+			want: "q=a+b&card=****4567&token=***",
+		},
+		{
+			name: "not redacted",
+			raw:  "q=1&b=2",
+			want: "q=1&b=2",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := r.query(tt.raw); got != tt.want {
+				t.Errorf("query() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedactor_json(t *testing.T) {
+	tests := []struct {
+		name   string
+		r      *Redactor
+		b      string
+		want   string
+		wantOK bool
+	}{
+		{
+			name:   "nil redactor",
+			b:      `{"password":"a"}`,
+			want:   `{"password":"a"}`,
+			wantOK: true,
+		},
+		{
+			name:   "drop",
+			r:      NewRedactor(RedactJSONPaths(RedactDrop, "$.password")),
+			b:      `{"password":"a","name":"b"}`,
+			want:   `{"name":"b"}`,
+			wantOK: true,
+		},
+		{
+			name:   "nested in array",
+			r:      NewRedactor(RedactJSONPaths(RedactPartial, "cards.number")),
+			b:      `{"cards":[{"number":"4111111111111111"},{"number":1234567}]}`,
+			want:   `{"cards":[{"number":"************1111"},{"number":"***4567"}]}`,
+			wantOK: true,
+		},
+		{
+			name:   "wildcard",
+			r:      NewRedactor(RedactJSONPaths(RedactMask, "*.token")),
+			b:      `{"a":{"token":"x"},"b":{"token":"y"}}`,
+			want:   `{"a":{"token":"***"},"b":{"token":"***"}}`,
+			wantOK: true,
+		},
+		{
+			name:   "any levels",
+			r:      NewRedactor(RedactJSONPaths(RedactMask, "**.token")),
+			b:      `{"token":"x","a":{"b":{"token":{"c":1}}}}`,
+			want:   `{"a":{"b":{"token":"***"}},"token":"***"}`,
+			wantOK: true,
+		},
+		{
+			name:   "invalid",
+			r:      NewRedactor(RedactJSONPaths(RedactMask, "token")),
+			b:      `{"token":"x`,
+			wantOK: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := tt.r.json([]byte(tt.b))
+			if ok != tt.wantOK || string(got) != tt.want {
+				t.Errorf("json() = %s, %v, want %s, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestRedactor_proto(t *testing.T) {
+	tests := []struct {
+		name string
+		r    *Redactor
+		want string
+	}{
+		{
+			name: "not redacted",
+			r:    NewRedactor(RedactProtoFields(RedactMask, "password")),
+			want: "karrot-market",
+		},
+		{
+			name: "masked by name",
+			r:    NewRedactor(RedactProtoFields(RedactMask, "name")),
+			want: "***",
+		},
+		{
+			name: "partial by full name",
+			r:    NewRedactor(RedactProtoFields(RedactPartial, "helloworld.HelloRequest.name")),
+			want: "*********rket",
+		},
+		{
+			name: "dropped",
+			r:    NewRedactor(RedactProtoFields(RedactDrop, "name")),
+			want: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &pb.HelloRequest{Name: "karrot-market"}
+			got := tt.r.proto(m).(*pb.HelloRequest)
+			if got.GetName() != tt.want {
+				t.Errorf("proto() = %v, want %v", got.GetName(), tt.want)
+			}
+			if m.GetName() != "karrot-market" {
+				t.Errorf("proto() modified the original message")
+			}
+		})
+	}
+}
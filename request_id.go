@@ -0,0 +1,111 @@
+package accesslog
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	defaultRequestIDKey = "X-Request-Id"
+	maxRequestIDLen     = 128
+)
+
+// requestIDer is implemented by LogEntry having the request ID.
+type requestIDer interface {
+	RequestID() string
+}
+
+// GetRequestID returns the request ID of the LogEntry in ctx, read from the request or generated by NewRequestID.
+// It returns "" if ctx has no LogEntry or WithRequestID isn't set.
+func GetRequestID(ctx context.Context) string {
+	if le, ok := GetLogEntry(ctx).(requestIDer); ok {
+		return le.RequestID()
+	}
+	return ""
+}
+
+// NewRequestID returns a new UUIDv7, which is sortable by the time it was generated.
+func NewRequestID() string {
+	var b [16]byte
+	ms := uint64(time.Now().UnixMilli())
+	for i := 0; i < 6; i++ {
+		b[i] = byte(ms >> (40 - 8*i))
+	}
+	_, _ = rand.Read(b[6:])
+	b[6] = b[6]&0x0f | 0x70
+	b[8] = b[8]&0x3f | 0x80
+
+	var s [36]byte
+	hex.Encode(s[0:8], b[0:4])
+	s[8] = '-'
+	hex.Encode(s[9:13], b[4:6])
+	s[13] = '-'
+	hex.Encode(s[14:18], b[6:8])
+	s[18] = '-'
+	hex.Encode(s[19:23], b[8:10])
+	s[23] = '-'
+	hex.Encode(s[24:], b[10:])
+	return string(s[:])
+}
+
+// validRequestID reports whether the incoming request ID can be logged as it is.
+// IDs too long or with characters other than printable ASCII are replaced not to break logs.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLen {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// requestID returns the incoming request ID if valid, or a new one. It returns "" if WithRequestID isn't set.
+func (cfg *commonConfig) requestID(incoming string) string {
+	if cfg.requestIDKey == "" {
+		return ""
+	}
+	if id := strings.TrimSpace(incoming); validRequestID(id) {
+		return id
+	}
+	return NewRequestID()
+}
+
+// grpcRequestID returns the request ID of the incoming metadata in ctx, or a new one,
+// and sets it to the header of the response. It returns "" if WithRequestID isn't set.
+func (cfg *commonConfig) grpcRequestID(ctx context.Context) string {
+	if cfg.requestIDKey == "" {
+		return ""
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	id := cfg.requestID(metadataGetter(md)(cfg.requestIDKey))
+	_ = grpc.SetHeader(ctx, metadata.Pairs(cfg.requestIDKey, id))
+	return id
+}
+
+// clientRequestID returns the request ID of the outgoing request got by get, or the one of the LogEntry in ctx.
+// It returns "" if WithRequestID isn't set.
+func (cfg *commonConfig) clientRequestID(ctx context.Context, get func(key string) string) string {
+	if cfg.requestIDKey == "" {
+		return ""
+	}
+	if id := strings.TrimSpace(get(cfg.requestIDKey)); validRequestID(id) {
+		return id
+	}
+	return GetRequestID(ctx)
+}
+
+// writeRequestID writes the request ID if any.
+func writeRequestID(f *fields, id string) {
+	if id != "" {
+		f.Str("request_id", id)
+	}
+}
@@ -0,0 +1,121 @@
+package accesslog
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	chi_middleware "github.com/go-chi/chi/v5/middleware"
+	"google.golang.org/grpc/metadata"
+)
+
+var uuidV7 = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-7[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+func TestNewRequestID(t *testing.T) {
+	a, b := NewRequestID(), NewRequestID()
+	if !uuidV7.MatchString(a) || !uuidV7.MatchString(b) {
+		t.Fatalf("NewRequestID() = %s, %s", a, b)
+	}
+	if a == b {
+		t.Errorf("NewRequestID() must be unique: %s", a)
+	}
+	if a[:8] > b[:8] {
+		t.Errorf("NewRequestID() must be sortable by time: %s, %s", a, b)
+	}
+}
+
+func Test_validRequestID(t *testing.T) {
+	tests := []struct {
+		id   string
+		want bool
+	}{
+		{id: "abc-123", want: true},
+		{id: ""},
+		{id: "a b"},
+		{id: "a\nb"},
+		{id: strings.Repeat("a", maxRequestIDLen+1)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			if got := validRequestID(tt.id); got != tt.want {
+				t.Errorf("validRequestID() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDefaultHTTPLogFormatter_requestID(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     []httpOption
+		incoming string
+		want     func(id string) bool
+	}{
+		{
+			name: "disabled",
+			want: func(id string) bool { return id == "" },
+		},
+		{
+			name:     "incoming",
+			opts:     []httpOption{WithRequestID("")},
+			incoming: "rid-1",
+			want:     func(id string) bool { return id == "rid-1" },
+		},
+		{
+			name:     "generated for invalid",
+			opts:     []httpOption{WithRequestID("X-Correlation-Id")},
+			incoming: "bad id",
+			want:     uuidV7.MatchString,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := NewDefaultHTTPLogFormatter(tt.opts...)
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.incoming != "" {
+				r.Header.Set(defaultRequestIDKey, tt.incoming)
+				r.Header.Set("X-Correlation-Id", tt.incoming)
+			}
+			rec := httptest.NewRecorder()
+			le := f.NewLogEntry(nil, r, chi_middleware.NewWrapResponseWriter(rec, 1))
+
+			id := GetRequestID(SetLogEntry(context.Background(), le))
+			if !tt.want(id) {
+				t.Errorf("GetRequestID() = %q", id)
+			}
+			if key := f.cfg.requestIDKey; key != "" && rec.Header().Get(key) != id {
+				t.Errorf("response header = %q, want %q", rec.Header().Get(key), id)
+			}
+		})
+	}
+}
+
+func TestCommonConfig_grpcRequestID(t *testing.T) {
+	cfg := &commonConfig{requestIDKey: defaultRequestIDKey}
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("x-request-id", "rid-1"))
+	if got := cfg.grpcRequestID(ctx); got != "rid-1" {
+		t.Errorf("grpcRequestID() = %q, want rid-1", got)
+	}
+	if got := cfg.grpcRequestID(context.Background()); !uuidV7.MatchString(got) {
+		t.Errorf("grpcRequestID() = %q", got)
+	}
+}
+
+func TestCommonConfig_clientRequestID(t *testing.T) {
+	cfg := &commonConfig{requestIDKey: defaultRequestIDKey}
+	ctx := SetLogEntry(context.Background(), &DefaultGRPCLogEntry{requestID: "server"})
+	none := func(string) string { return "" }
+
+	if got := cfg.clientRequestID(ctx, metadataGetter(metadata.Pairs("x-request-id", "outgoing"))); got != "outgoing" {
+		t.Errorf("clientRequestID() = %q, want outgoing", got)
+	}
+	if got := cfg.clientRequestID(ctx, none); got != "server" {
+		t.Errorf("clientRequestID() = %q, want server", got)
+	}
+	if got := (&commonConfig{}).clientRequestID(ctx, none); got != "" {
+		t.Errorf("clientRequestID() = %q, want empty", got)
+	}
+}
@@ -0,0 +1,266 @@
+package accesslog
+
+import (
+	"container/list"
+	"encoding/binary"
+	"encoding/hex"
+	"hash/fnv"
+	"math"
+	"math/rand"
+	"path"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+)
+
+// SamplingParams is the parameters of a finished request to decide whether its log entry is sampled.
+type SamplingParams struct {
+	// Protocol is "http" or "grpc".
+	Protocol string
+	// Method is the HTTP method or the full method of gRPC.
+	Method string
+	// Path is the route pattern if captured, otherwise the raw path. It is empty for gRPC.
+	Path string
+	// Route is the route pattern if captured. It is empty for gRPC.
+	Route string
+	// Status is the HTTP status code. It is 0 for gRPC.
+	Status int
+	// Code is the gRPC status code. It is codes.OK for HTTP.
+	Code codes.Code
+	// Elapsed is the elapsed time of the request.
+	Elapsed time.Duration
+	// TraceID is the trace ID propagated with the request if any.
+	TraceID string
+}
+
+// IsError reports whether the request failed, that is, the HTTP status is 5xx or the gRPC code isn't OK.
+func (p SamplingParams) IsError() bool {
+	if p.Protocol == "grpc" {
+		return p.Code != codes.OK
+	}
+	return p.Status >= 500
+}
+
+// Sampler decides whether a log entry should be written.
+type Sampler interface {
+	// Sample returns whether the log entry should be written, and the rate in (0, 1] it was sampled at.
+	// The rate is logged as "sample_rate" if it is less than 1, so that counts can be re-weighted.
+	Sample(p SamplingParams) (ok bool, rate float64)
+}
+
+// SamplerFunc is an adapter to allow the use of ordinary functions as Sampler.
+type SamplerFunc func(p SamplingParams) (bool, float64)
+
+// Sample calls f(p).
+func (f SamplerFunc) Sample(p SamplingParams) (bool, float64) {
+	return f(p)
+}
+
+// RateSampler returns a Sampler sampling entries at the fixed rate.
+// If the trace ID is propagated, the decision is deterministic by the trace ID in the same way as
+// the TraceIDRatioBased sampler of OpenTelemetry, so that a request sampled in one service is sampled in all.
+func RateSampler(rate float64) Sampler {
+	switch {
+	case rate >= 1:
+		return SamplerFunc(func(p SamplingParams) (bool, float64) {
+			return true, 1
+		})
+	case rate <= 0:
+		return SamplerFunc(func(p SamplingParams) (bool, float64) {
+			return false, 0
+		})
+	}
+
+	bound := uint64(rate * (1 << 63))
+	return SamplerFunc(func(p SamplingParams) (bool, float64) {
+		if p.TraceID != "" {
+			return traceIDBits(p.TraceID)>>1 < bound, rate
+		}
+		return rand.Float64() < rate, rate
+	})
+}
+
+// traceIDBits returns the lower 64 bits of the hex encoded trace ID.
+// If the trace ID isn't hex encoded, its FNV hash is used.
+func traceIDBits(traceID string) uint64 {
+	if b, err := hex.DecodeString(traceID); err == nil && len(b) >= 8 {
+		return binary.BigEndian.Uint64(b[len(b)-8:])
+	}
+	h := fnv.New64a()
+	h.Write([]byte(traceID))
+	return h.Sum64()
+}
+
+// SampleRule is the rule applying Sampler to matched requests.
+type SampleRule struct {
+	// Method is the HTTP method or the pattern of full methods of gRPC. Empty matches all.
+	// See path.Match method how to set patterns, e.g. "/grpc.health.v1.Health/*".
+	Method string
+	// Path is the pattern of paths. Empty matches all.
+	// See path.Match method how to set patterns.
+	Path string
+	// Sampler is applied to matched requests.
+	Sampler Sampler
+}
+
+// match reports whether p is matched by the rule.
+func (r SampleRule) match(p SamplingParams) bool {
+	if r.Method != "" {
+		if m, _ := path.Match(r.Method, p.Method); !m {
+			return false
+		}
+	}
+	if r.Path != "" {
+		if m, _ := path.Match(r.Path, p.Path); !m {
+			return false
+		}
+	}
+	return true
+}
+
+// RuleSampler returns a Sampler applying the Sampler of the first matched rule.
+// If no rule is matched, fallback is applied. A nil Sampler of a rule or a nil fallback samples all.
+func RuleSampler(fallback Sampler, rules ...SampleRule) Sampler {
+	return SamplerFunc(func(p SamplingParams) (bool, float64) {
+		for _, r := range rules {
+			if r.match(p) {
+				if r.Sampler == nil {
+					return true, 1
+				}
+				return r.Sampler.Sample(p)
+			}
+		}
+		if fallback != nil {
+			return fallback.Sample(p)
+		}
+		return true, 1
+	})
+}
+
+// ErrorOrSlowSampler returns a Sampler always sampling failed requests and requests slower than threshold.
+// The other requests are sampled by next. A nil next samples all.
+func ErrorOrSlowSampler(threshold time.Duration, next Sampler) Sampler {
+	return SamplerFunc(func(p SamplingParams) (bool, float64) {
+		if next == nil || p.IsError() || (threshold > 0 && p.Elapsed >= threshold) {
+			return true, 1
+		}
+		return next.Sample(p)
+	})
+}
+
+// SampleKeyByRoute returns the key of a request by its method and route pattern, or the full method of gRPC.
+// HTTP requests without route patterns share the key "http", since raw paths are unbounded.
+func SampleKeyByRoute(p SamplingParams) string {
+	switch {
+	case p.Protocol == "grpc":
+		return p.Method
+	case p.Route == "":
+		return "http"
+	}
+	return p.Method + " " + p.Route
+}
+
+// maxSampleBuckets is the maximum number of buckets of TokenBucketSampler.
+// The least recently used bucket is evicted when exceeded.
+const maxSampleBuckets = 10000
+
+// TokenBucketSampler returns a Sampler limiting entries up to perSecond with bursts of burst for each key.
+// If key is nil, SampleKeyByRoute is used. The keys should be bounded, e.g. route patterns instead of raw paths,
+// since only the buckets of the most recently used 10000 keys are kept.
+// The rate is estimated from the entries sampled in the last second for each key.
+func TokenBucketSampler(perSecond float64, burst int, key func(p SamplingParams) string) Sampler {
+	if key == nil {
+		key = SampleKeyByRoute
+	}
+	s := &tokenBucketSampler{
+		perSecond:  perSecond,
+		burst:      float64(burst),
+		key:        key,
+		maxBuckets: maxSampleBuckets,
+		buckets:    map[string]*list.Element{},
+		lru:        list.New(),
+		now:        time.Now,
+	}
+	return s
+}
+
+type tokenBucketSampler struct {
+	perSecond  float64
+	burst      float64
+	key        func(p SamplingParams) string
+	maxBuckets int
+	now        func() time.Time
+
+	mu      sync.Mutex
+	buckets map[string]*list.Element
+	lru     *list.List
+}
+
+type tokenBucket struct {
+	key    string
+	tokens float64
+	last   time.Time
+
+	window  time.Time
+	seen    int
+	allowed int
+}
+
+// Sample implements Sampler.
+func (s *tokenBucketSampler) Sample(p SamplingParams) (bool, float64) {
+	k := s.key(p)
+	now := s.now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b := s.bucket(k, now)
+
+	b.tokens = math.Min(s.burst, b.tokens+now.Sub(b.last).Seconds()*s.perSecond)
+	b.last = now
+	if now.Sub(b.window) >= time.Second {
+		b.window, b.seen, b.allowed = now, 0, 0
+	}
+
+	b.seen++
+	if b.tokens < 1 {
+		return false, float64(b.allowed) / float64(b.seen)
+	}
+	b.tokens--
+	b.allowed++
+	return true, float64(b.allowed) / float64(b.seen)
+}
+
+// bucket returns the bucket of the key k, and marks it as the most recently used.
+// A new bucket evicts the least recently used one if the buckets are full. s.mu must be held.
+func (s *tokenBucketSampler) bucket(k string, now time.Time) *tokenBucket {
+	if e, ok := s.buckets[k]; ok {
+		s.lru.MoveToFront(e)
+		return e.Value.(*tokenBucket)
+	}
+
+	if s.lru.Len() >= s.maxBuckets {
+		e := s.lru.Back()
+		s.lru.Remove(e)
+		delete(s.buckets, e.Value.(*tokenBucket).key)
+	}
+	b := &tokenBucket{key: k, tokens: s.burst, last: now, window: now}
+	s.buckets[k] = s.lru.PushFront(b)
+	return b
+}
+
+// sample decides whether the entry should be written by the sampler.
+func (cfg *commonConfig) sample(p SamplingParams) (bool, float64) {
+	if cfg.sampler == nil {
+		return true, 1
+	}
+	return cfg.sampler.Sample(p)
+}
+
+// writeSampleRate writes the sample rate if it is less than 1.
+func writeSampleRate(f *fields, rate float64) {
+	if rate < 1 {
+		f.Float64("sample_rate", rate)
+	}
+}
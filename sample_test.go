@@ -0,0 +1,175 @@
+package accesslog
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+)
+
+var never = SamplerFunc(func(p SamplingParams) (bool, float64) {
+	return false, 0
+})
+
+func TestRateSampler(t *testing.T) {
+	s := RateSampler(0.25)
+
+	sampled := 0
+	for i := 0; i < 10000; i++ {
+		h := sha256.Sum256([]byte(strconv.Itoa(i)))
+		p := SamplingParams{TraceID: hex.EncodeToString(h[:16])}
+		ok, rate := s.Sample(p)
+		if rate != 0.25 {
+			t.Fatalf("Sample() rate = %v, want %v", rate, 0.25)
+		}
+		if again, _ := s.Sample(p); again != ok {
+			t.Fatalf("Sample() is not deterministic for trace ID %v", p.TraceID)
+		}
+		if ok {
+			sampled++
+		}
+	}
+	if sampled < 2000 || sampled > 3000 {
+		t.Errorf("Sample() sampled %v of 10000, want about 2500", sampled)
+	}
+}
+
+func TestRuleSampler(t *testing.T) {
+	s := RuleSampler(nil,
+		SampleRule{Method: "/grpc.health.v1.Health/*", Sampler: never},
+		SampleRule{Method: "GET", Path: "/health*", Sampler: never},
+		SampleRule{Method: "PUT"},
+	)
+	tests := []struct {
+		name string
+		p    SamplingParams
+		want bool
+	}{
+		{
+			name: "grpc method",
+			p:    SamplingParams{Protocol: "grpc", Method: "/grpc.health.v1.Health/Check"},
+			want: false,
+		},
+		{
+			name: "http path",
+			p:    SamplingParams{Protocol: "http", Method: "GET", Path: "/healthz"},
+			want: false,
+		},
+		{
+			name: "nil sampler",
+			p:    SamplingParams{Protocol: "http", Method: "PUT", Path: "/healthz"},
+			want: true,
+		},
+		{
+			name: "fallback",
+			p:    SamplingParams{Protocol: "http", Method: "POST", Path: "/healthz"},
+			want: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, _ := s.Sample(tt.p); got != tt.want {
+				t.Errorf("Sample() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestErrorOrSlowSampler(t *testing.T) {
+	s := ErrorOrSlowSampler(time.Second, never)
+	tests := []struct {
+		name string
+		p    SamplingParams
+		want bool
+	}{
+		{
+			name: "http error",
+			p:    SamplingParams{Protocol: "http", Status: 503},
+			want: true,
+		},
+		{
+			name: "grpc error",
+			p:    SamplingParams{Protocol: "grpc", Code: codes.Unavailable},
+			want: true,
+		},
+		{
+			name: "slow",
+			p:    SamplingParams{Protocol: "http", Status: 200, Elapsed: 2 * time.Second},
+			want: true,
+		},
+		{
+			name: "fast and ok",
+			p:    SamplingParams{Protocol: "grpc", Code: codes.OK, Elapsed: time.Millisecond},
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, _ := s.Sample(tt.p); got != tt.want {
+				t.Errorf("Sample() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestErrorOrSlowSampler_nil(t *testing.T) {
+	if ok, rate := ErrorOrSlowSampler(time.Second, nil).Sample(SamplingParams{Protocol: "http", Status: 200}); !ok || rate != 1 {
+		t.Errorf("Sample() = %v, %v, want true, 1", ok, rate)
+	}
+}
+
+func TestTokenBucketSampler(t *testing.T) {
+	now := time.Date(2021, 12, 9, 0, 0, 0, 0, time.UTC)
+	s := TokenBucketSampler(1, 2, nil).(*tokenBucketSampler)
+	s.now = func() time.Time { return now }
+
+	p := SamplingParams{Protocol: "http", Method: "GET", Path: "/ping", Route: "/ping"}
+	var got []bool
+	for i := 0; i < 4; i++ {
+		ok, _ := s.Sample(p)
+		got = append(got, ok)
+	}
+	if want := []bool{true, true, false, false}; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("Sample() = %v, want %v", got, want)
+	}
+	if _, rate := s.Sample(p); rate != 0.4 {
+		t.Errorf("Sample() rate = %v, want %v", rate, 0.4)
+	}
+	if ok, _ := s.Sample(SamplingParams{Protocol: "http", Method: "GET", Path: "/other", Route: "/other"}); !ok {
+		t.Errorf("Sample() = false for another key, want true")
+	}
+
+	now = now.Add(time.Second)
+	if ok, _ := s.Sample(p); !ok {
+		t.Errorf("Sample() = false after refill, want true")
+	}
+}
+
+func TestTokenBucketSampler_buckets(t *testing.T) {
+	s := TokenBucketSampler(1, 1, nil).(*tokenBucketSampler)
+	s.maxBuckets = 2
+
+	// raw paths without routes share a bucket.
+	for i := 0; i < 3; i++ {
+		s.Sample(SamplingParams{Protocol: "http", Method: "GET", Path: fmt.Sprintf("/random/%d", i)})
+	}
+	if got := len(s.buckets); got != 1 {
+		t.Errorf("buckets = %d, want 1", got)
+	}
+
+	s.Sample(SamplingParams{Protocol: "http", Method: "GET", Route: "/a"})
+	s.Sample(SamplingParams{Protocol: "grpc", Method: "/svc/M"})
+	if got := len(s.buckets); got != 2 || s.lru.Len() != 2 {
+		t.Errorf("buckets = %d, want 2", got)
+	}
+	if _, ok := s.buckets["http"]; ok {
+		t.Error("the least recently used bucket must be evicted")
+	}
+	if _, ok := s.buckets["/svc/M"]; !ok {
+		t.Error("the most recently used bucket must be kept")
+	}
+}
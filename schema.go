@@ -0,0 +1,667 @@
+package accesslog
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ColumnType is the type of a column in the schema of logs.
+type ColumnType int
+
+const (
+	// StringColumn is the column of strings.
+	StringColumn ColumnType = iota
+	// IntColumn is the column of integers.
+	IntColumn
+	// FloatColumn is the column of floating point numbers.
+	FloatColumn
+	// BoolColumn is the column of booleans.
+	BoolColumn
+	// StringsColumn is the column of arrays of strings.
+	StringsColumn
+	// JSONColumn is the column of any JSON values, e.g. bodies embedded as raw JSON. It is a string in Athena.
+	JSONColumn
+	// StructColumn is the column of objects of the fields in Column.Fields, e.g. "httpRequest" of GCPNaming.
+	StructColumn
+)
+
+// athena returns the type name of c in Athena/Hive.
+func (c Column) athena() string {
+	if c.Type == StructColumn {
+		fs := make([]string, 0, len(c.Fields))
+		for _, f := range c.Fields {
+			fs = append(fs, f.Identifier()+":"+f.athena())
+		}
+		return "struct<" + strings.Join(fs, ",") + ">"
+	}
+	return c.Type.athena()
+}
+
+// athena returns the type name in Athena/Hive.
+func (t ColumnType) athena() string {
+	switch t {
+	case IntColumn:
+		return "bigint"
+	case FloatColumn:
+		return "double"
+	case BoolColumn:
+		return "boolean"
+	case StringsColumn:
+		return "array<string>"
+	default:
+		return "string"
+	}
+}
+
+// bigQuery returns the type name in BigQuery. StringsColumn is STRING in the REPEATED mode.
+func (t ColumnType) bigQuery() string {
+	switch t {
+	case IntColumn:
+		return "INTEGER"
+	case FloatColumn:
+		return "FLOAT"
+	case BoolColumn:
+		return "BOOLEAN"
+	case JSONColumn:
+		return "JSON"
+	case StructColumn:
+		return "RECORD"
+	default:
+		return "STRING"
+	}
+}
+
+// jsonSchema returns the type name in JSON Schema. JSONColumn has no type to accept any values.
+func (t ColumnType) jsonSchema() string {
+	switch t {
+	case JSONColumn:
+		return ""
+	case IntColumn:
+		return "integer"
+	case FloatColumn:
+		return "number"
+	case BoolColumn:
+		return "boolean"
+	case StringsColumn:
+		return "array"
+	case StructColumn:
+		return "object"
+	default:
+		return "string"
+	}
+}
+
+// Column is a field of logs.
+type Column struct {
+	// Name is the name of the field in logs.
+	Name string
+	// Type is the type of the field.
+	Type ColumnType
+	// Description is the description of the field.
+	Description string
+	// Fields are the fields of StructColumn.
+	Fields []Column
+}
+
+// Identifier returns the name usable as a column name in Athena and BigQuery.
+// Characters other than letters, digits and underscores are replaced with underscores, e.g. "elapsed(ms)" to "elapsed_ms".
+func (c Column) Identifier() string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(c.Name) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '_' {
+			b.WriteRune(r)
+		} else {
+			b.WriteByte('_')
+		}
+	}
+	s := strings.Trim(b.String(), "_")
+	if s == "" || (s[0] >= '0' && s[0] <= '9') {
+		s = "_" + s
+	}
+	return s
+}
+
+// Schema is the schema of logs produced by a formatter.
+// Partitions are the Hive-style partitions of objects uploaded by writer.S3LogWriter.
+// They are partitioned by the field of the protocol named by the naming of the formatter,
+// so the writer should be given it by writer.S3PartitionField unless it is "protocol". See PartitionBy for other fields.
+type Schema struct {
+	Columns    []Column
+	Partitions []Column
+
+	protocols     []string
+	protocolField string
+}
+
+// partitionColumns returns the partitions of objects uploaded by writer.S3LogWriter partitioned by field.
+func partitionColumns(field, protocolField string) []Column {
+	desc := fmt.Sprintf("The value of the field %s.", field)
+	if field == protocolField {
+		desc = "The protocol of the request, http or grpc."
+	}
+	return []Column{
+		{Name: "dt", Type: StringColumn, Description: "The date the log was written in UTC, e.g. 2021-12-09."},
+		{Name: "hour", Type: StringColumn, Description: "The hour the log was written in UTC, e.g. 02."},
+		{Name: field, Type: StringColumn, Description: desc},
+	}
+}
+
+// newSchema returns the schema of cs for logs of protocol, partitioned by the field of the protocol.
+func newSchema(cfg *commonConfig, protocol string, cs []Column) *Schema {
+	pf := cfg.naming.name(protocol, "protocol")
+	if pf == "" {
+		// the field is dropped, so that the writer partitions logs as unknown.
+		pf = "protocol"
+	}
+	return &Schema{
+		Columns:       cfg.nestColumns(cs),
+		Partitions:    partitionColumns(pf, pf),
+		protocols:     []string{protocol},
+		protocolField: pf,
+	}
+}
+
+// PartitionBy returns a copy of s partitioned by field like writer.S3PartitionField(field).
+func (s *Schema) PartitionBy(field string) *Schema {
+	c := *s
+	c.Partitions = partitionColumns(field, s.protocolField)
+	return &c
+}
+
+// HTTPSchema returns the schema of logs produced by f, including the fields enabled by rules.
+// Fields added by LogEntry.Add aren't included.
+func HTTPSchema(f *DefaultHTTPLogFormatter) *Schema {
+	cfg := f.cfg.schemaConfig()
+	var cs []Column
+	add := func(c ...Column) {
+		cs = append(cs, cfg.namedColumns("http", c)...)
+	}
+	add(
+		Column{Name: "protocol", Type: StringColumn, Description: "The protocol of the request."},
+		Column{Name: "path", Type: StringColumn, Description: "The path of the request."},
+	)
+	add(cfg.statusColumns("http")...)
+	add(Column{Name: "ua", Type: StringColumn, Description: "The user agent of the request."})
+	add(cfg.timeColumns()...)
+	add(Column{Name: "qs", Type: StringColumn, Description: "The query string of the request."})
+	if cfg.naming.named("http", "url") {
+		add(Column{Name: "url", Type: StringColumn, Description: "The full URL of the request."})
+	}
+	if cfg.routePattern != nil && !cfg.routeAsPath {
+		add(Column{Name: "route", Type: StringColumn, Description: "The route pattern matched by the request."})
+	}
+	if cfg.withMethod {
+		add(Column{Name: "method", Type: StringColumn, Description: "The method of the request."})
+	}
+	if cfg.withBytesIn {
+		add(Column{Name: "bytes_in", Type: IntColumn, Description: "The size of the request body."})
+	}
+	if cfg.withBytesOut {
+		add(Column{Name: "bytes_out", Type: IntColumn, Description: "The size of the response body."})
+	}
+	if cfg.withProto {
+		add(Column{Name: "proto", Type: StringColumn, Description: "The protocol version of the request."})
+	}
+	if cfg.withHost {
+		add(Column{Name: "host", Type: StringColumn, Description: "The host of the request."})
+	}
+	if cfg.withScheme {
+		add(Column{Name: "scheme", Type: StringColumn, Description: "The scheme of the request."})
+	}
+	cs = append(cs, cfg.aliasColumns("http", cfg.headers, StringColumn, "The header %s of the request.")...)
+	if cfg.withRequestBody {
+		add(
+			Column{Name: "req_body", Type: JSONColumn, Description: "The body of the request, embedded as JSON if the body is JSON."},
+			Column{Name: "req_body_truncated", Type: BoolColumn, Description: "Whether the body of the request is truncated."},
+		)
+	}
+	if cfg.withResponseBody {
+		add(
+			Column{Name: "res_body", Type: JSONColumn, Description: "The body of the response, embedded as JSON if the body is JSON."},
+			Column{Name: "res_body_truncated", Type: BoolColumn, Description: "Whether the body of the response is truncated."},
+		)
+	}
+	if cfg.withClientIP {
+		add(Column{Name: "client-ip", Type: StringColumn, Description: "The IP of the client."})
+	}
+	for _, kv := range cfg.staticFields {
+		add(Column{Name: kv[0], Type: StringColumn, Description: "The static field of rules."})
+	}
+	add(panicColumns...)
+	add(cfg.commonConfig.columns()...)
+
+	return newSchema(&cfg.commonConfig, "http", cs)
+}
+
+// GRPCSchema returns the schema of logs produced by f, including the fields of streams and the ones enabled by rules.
+// Fields added by LogEntry.Add aren't included.
+func GRPCSchema(f *DefaultGRPCLogFormatter) *Schema {
+	cfg := f.cfg.schemaConfig()
+	var cs []Column
+	add := func(c ...Column) {
+		cs = append(cs, cfg.namedColumns("grpc", c)...)
+	}
+	add(
+		Column{Name: "protocol", Type: StringColumn, Description: "The protocol of the request."},
+		Column{Name: "method", Type: StringColumn, Description: "The full method of the request."},
+	)
+	add(cfg.statusColumns("grpc")...)
+	add(cfg.timeColumns()...)
+	add([]Column{
+		{Name: "stream", Type: StringColumn, Description: "The kind of the stream."},
+		{Name: "msgs_sent", Type: IntColumn, Description: "The number of messages sent to the stream."},
+		{Name: "msgs_recv", Type: IntColumn, Description: "The number of messages received from the stream."},
+		{Name: "bytes_sent", Type: IntColumn, Description: "The bytes of messages sent to the stream."},
+		{Name: "bytes_recv", Type: IntColumn, Description: "The bytes of messages received from the stream."},
+	}...)
+	if cfg.v2() {
+		cs = append(cs, cfg.aliasColumns("grpc", cfg.metadata, StringsColumn, "The metadata %s of the request.")...)
+	} else {
+		cs = append(cs, cfg.aliasColumns("grpc", cfg.metadata, StringColumn, "The metadata %s of the request in a JSON array.")...)
+	}
+	if cfg.withPeer {
+		add(Column{Name: "peer", Type: StringColumn, Description: "The address of the peer."})
+	}
+	if cfg.withRequest {
+		add(Column{Name: "req", Type: JSONColumn, Description: messageDescription("request")})
+	}
+	if cfg.withResponse {
+		add(Column{Name: "res", Type: JSONColumn, Description: messageDescription("response")})
+	}
+	add(cfg.errorColumns()...)
+	add(panicColumns...)
+	add(cfg.commonConfig.columns()...)
+
+	return newSchema(&cfg.commonConfig, "grpc", cs)
+}
+
+// messageDescription returns the description of the column of messages written by writeMessage.
+func messageDescription(kind string) string {
+	return fmt.Sprintf(`The %s message in a JSON object, or {"truncated":true,"json":"..."} if truncated.`, kind)
+}
+
+// MergeSchemas returns the schema of logs produced by all formatters of ss, e.g. for a table of both HTTP and gRPC logs.
+// If columns of the same name have different types, the first one is used, so the formatters should use the same schema version.
+// The partitions of the first schema are used.
+func MergeSchemas(ss ...*Schema) *Schema {
+	m := &Schema{}
+	seen := map[string]bool{}
+	seenProtocol := map[string]bool{}
+	for _, s := range ss {
+		for _, c := range s.Columns {
+			if !seen[c.Name] {
+				seen[c.Name] = true
+				m.Columns = append(m.Columns, c)
+			}
+		}
+		if m.Partitions == nil {
+			m.Partitions, m.protocolField = s.Partitions, s.protocolField
+		}
+		for _, p := range s.protocols {
+			if !seenProtocol[p] {
+				seenProtocol[p] = true
+				m.protocols = append(m.protocols, p)
+			}
+		}
+	}
+	return m
+}
+
+// aliasColumns returns the columns of headers or metadata logged with aliases, named by the naming and sorted by name.
+func (cfg *commonConfig) aliasColumns(protocol string, m map[string]string, t ColumnType, desc string) []Column {
+	var cs []Column
+	for k, a := range m {
+		if n := cfg.naming.aliasName(protocol, k, a); n != "" {
+			cs = append(cs, Column{Name: n, Type: t, Description: fmt.Sprintf(desc, k)})
+		}
+	}
+	sort.Slice(cs, func(i, j int) bool {
+		return cs[i].Name < cs[j].Name
+	})
+	return cs
+}
+
+// namedColumns returns cs named by the naming of the protocol, without dropped columns.
+// The elapsed time is retyped to the format of the naming.
+func (cfg *commonConfig) namedColumns(protocol string, cs []Column) []Column {
+	if cfg.naming == nil {
+		return cs
+	}
+	named := make([]Column, 0, len(cs))
+	for _, c := range cs {
+		n := cfg.naming.name(protocol, c.Name)
+		if n == "" {
+			continue
+		}
+		if c.Name == "elapsed(ms)" || c.Name == "elapsed_us" {
+			switch cfg.naming.durationFormat() {
+			case durationNanos:
+				c.Type, c.Description = IntColumn, "The elapsed time of the request in nanoseconds."
+			case durationString:
+				c.Type, c.Description = StringColumn, "The elapsed time of the request in seconds with the suffix s."
+			}
+		}
+		c.Name = n
+		named = append(named, c)
+	}
+	return named
+}
+
+// nestColumns groups the columns nested by the naming into StructColumn as written by fields,
+// e.g. "httpRequest.status" into the field "status" of the column "httpRequest".
+func (cfg *commonConfig) nestColumns(cs []Column) []Column {
+	if cfg.naming == nil || !cfg.naming.nested {
+		return cs
+	}
+	nested := make([]Column, 0, len(cs))
+	groups := map[string]int{}
+	for _, c := range cs {
+		g, k, ok := splitNested(c.Name)
+		if !ok {
+			nested = append(nested, c)
+			continue
+		}
+		i, ok := groups[g]
+		if !ok {
+			i = len(nested)
+			groups[g] = i
+			nested = append(nested, Column{Name: g, Type: StructColumn, Description: fmt.Sprintf("The fields nested in %s.", g)})
+		}
+		c.Name = k
+		nested[i].Fields = append(nested[i].Fields, c)
+	}
+	return nested
+}
+
+// statusColumns returns the columns of the status written by writeHTTPStatus or writeGRPCStatus.
+func (cfg *commonConfig) statusColumns(protocol string) []Column {
+	if !cfg.v2() {
+		return []Column{{Name: "status", Type: StringColumn, Description: "The status code of the response."}}
+	}
+	if protocol == "grpc" {
+		return []Column{
+			{Name: "status", Type: IntColumn, Description: "The status code of the response."},
+			{Name: "status_name", Type: StringColumn, Description: "The name of the status code of the response."},
+		}
+	}
+	return []Column{{Name: "status", Type: IntColumn, Description: "The status code of the response."}}
+}
+
+// timeColumns returns the columns written by writeTime.
+func (cfg *commonConfig) timeColumns() []Column {
+	cs := []Column{{Name: "time", Type: StringColumn, Description: "The time the request started in RFC3339."}}
+	if cfg.timeFormat == TimeUnixMilli {
+		cs[0] = Column{Name: "time", Type: IntColumn, Description: "The time the request started in milliseconds since the Unix epoch."}
+	}
+	if cfg.v2() {
+		return append(cs,
+			Column{Name: "elapsed_us", Type: IntColumn, Description: "The elapsed time of the request in microseconds."},
+			Column{Name: "schema_version", Type: IntColumn, Description: "The version of the layout of the log."},
+		)
+	}
+	return append(cs, Column{Name: "elapsed(ms)", Type: FloatColumn, Description: "The elapsed time of the request in milliseconds."})
+}
+
+// errorColumns returns the columns written by writeError.
+func (cfg *grpcConfig) errorColumns() []Column {
+	var cs []Column
+	if cfg.withErrorOrigin {
+		cs = append(cs, Column{Name: "error_origin", Type: StringColumn, Description: "The origin of the error: handler, server, canceled, deadline_exceeded or panic."})
+	}
+	if cfg.withStatusMessage {
+		cs = append(cs, Column{Name: "error", Type: StringColumn, Description: "The message of the status of the response."})
+	}
+	if cfg.withErrorDetails {
+		violations := Column{Name: "field_violations", Type: StringColumn, Description: "The field violations of BadRequest in a JSON array."}
+		if cfg.v2() {
+			violations = Column{Name: "field_violations", Type: StringsColumn, Description: "The field violations of BadRequest."}
+		}
+		cs = append(cs,
+			Column{Name: "error_reason", Type: StringColumn, Description: "The reason of ErrorInfo."},
+			Column{Name: "error_domain", Type: StringColumn, Description: "The domain of ErrorInfo."},
+			violations,
+			Column{Name: "retry_delay_ms", Type: IntColumn, Description: "The retry delay of RetryInfo in milliseconds."},
+			Column{Name: "debug_detail", Type: StringColumn, Description: "The detail of DebugInfo."},
+			Column{Name: "debug_stack", Type: StringColumn, Description: "The stack entries of DebugInfo."},
+		)
+	}
+	return cs
+}
+
+// panicColumns are the columns written by writePanic if the middleware recovered a panic.
+var panicColumns = []Column{
+	{Name: "panic", Type: StringColumn, Description: "The value of the panic recovered by the middleware."},
+	{Name: "stack", Type: StringColumn, Description: "The stack trace of the panic."},
+}
+
+// columns returns the columns written by the common options.
+func (cfg *commonConfig) columns() []Column {
+	var cs []Column
+	if cfg.requestIDKey != "" {
+		cs = append(cs, Column{Name: "request_id", Type: StringColumn, Description: "The ID of the request."})
+	}
+	if cfg.withTrace {
+		cs = append(cs,
+			Column{Name: "trace_id", Type: StringColumn, Description: "The trace ID of the request in hex."},
+			Column{Name: "span_id", Type: StringColumn, Description: "The span ID of the request in hex."},
+			Column{Name: "trace_flags", Type: StringColumn, Description: "The trace flags of the request in hex, e.g. 01 if sampled."},
+		)
+	}
+	if cfg.sampler != nil {
+		cs = append(cs, Column{Name: "sample_rate", Type: FloatColumn, Description: "The rate the log was sampled at if less than 1."})
+	}
+	return cs
+}
+
+// dataColumns returns the columns except partitions, since a column can't be a partition at the same time.
+func (s *Schema) dataColumns() []Column {
+	ps := make(map[string]bool, len(s.Partitions))
+	for _, p := range s.Partitions {
+		ps[p.Name] = true
+	}
+	var cs []Column
+	for _, c := range s.Columns {
+		if !ps[c.Name] {
+			cs = append(cs, c)
+		}
+	}
+	return cs
+}
+
+// StorageFormat is the format of log files read by a table.
+type StorageFormat int
+
+const (
+	// JSONStorage is JSON lines written by the writers without encoders.
+	JSONStorage StorageFormat = iota
+	// ParquetStorage is Parquet encoded by writer.ParquetEncoder with the schema,
+	// having the map column "extra" of the fields not in the schema.
+	ParquetStorage
+)
+
+// parquetExtraColumn is the default name of the column of writer.ParquetEncoder for the fields not in the schema.
+const parquetExtraColumn = "extra"
+
+// AthenaDDL returns the CREATE TABLE statement of Athena for logs in format under location, e.g. "s3://bucket/accesslog/".
+// JSON logs are read with the OpenX JSON SerDe, which maps identifiers to field names, and Parquet logs are read
+// by the identifiers of columns. Partitions are found by partition projection without crawlers.
+// If the extra column of Parquet is renamed by writer.ParquetExtraColumn, rename it in the statement too.
+func (s *Schema) AthenaDDL(table, location string, format StorageFormat) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "CREATE EXTERNAL TABLE IF NOT EXISTS `%s` (\n", table)
+	cs := s.dataColumns()
+	for i, c := range cs {
+		fmt.Fprintf(&b, "  `%s` %s", c.Identifier(), c.athena())
+		if c.Description != "" {
+			fmt.Fprintf(&b, " COMMENT '%s'", strings.ReplaceAll(c.Description, "'", "\\'"))
+		}
+		if i < len(cs)-1 || format == ParquetStorage {
+			b.WriteByte(',')
+		}
+		b.WriteByte('\n')
+	}
+	if format == ParquetStorage {
+		fmt.Fprintf(&b, "  `%s` map<string,string> COMMENT 'The fields not in the schema, in JSON unless strings.'\n", parquetExtraColumn)
+	}
+	b.WriteString(")\n")
+
+	if len(s.Partitions) != 0 {
+		b.WriteString("PARTITIONED BY (\n")
+		for i, p := range s.Partitions {
+			fmt.Fprintf(&b, "  `%s` %s", p.Identifier(), p.Type.athena())
+			if i < len(s.Partitions)-1 {
+				b.WriteByte(',')
+			}
+			b.WriteByte('\n')
+		}
+		b.WriteString(")\n")
+	}
+
+	if format == ParquetStorage {
+		b.WriteString("STORED AS PARQUET\n")
+	} else {
+		b.WriteString("ROW FORMAT SERDE 'org.openx.data.jsonserde.JsonSerDe'\n")
+		var mappings []string
+		for _, c := range cs {
+			if id := c.Identifier(); id != c.Name {
+				mappings = append(mappings, fmt.Sprintf("'mapping.%s' = '%s'", id, c.Name))
+			}
+		}
+		writeProperties(&b, "WITH SERDEPROPERTIES", mappings)
+	}
+
+	location = strings.TrimSuffix(location, "/") + "/"
+	fmt.Fprintf(&b, "LOCATION '%s'\n", location)
+	writeProperties(&b, "TBLPROPERTIES", s.projection(location))
+
+	return strings.TrimSuffix(b.String(), "\n") + ";\n"
+}
+
+// projection returns the table properties of partition projection for partitions under location.
+func (s *Schema) projection(location string) []string {
+	if len(s.Partitions) == 0 {
+		return nil
+	}
+
+	props := []string{"'projection.enabled' = 'true'"}
+	var tmpl []string
+	for _, p := range s.Partitions {
+		id := p.Identifier()
+		switch p.Name {
+		case "dt":
+			props = append(props,
+				"'projection.dt.type' = 'date'",
+				"'projection.dt.format' = 'yyyy-MM-dd'",
+				"'projection.dt.range' = 'NOW-1YEARS,NOW'",
+			)
+		case "hour":
+			props = append(props,
+				"'projection.hour.type' = 'integer'",
+				"'projection.hour.range' = '0,23'",
+				"'projection.hour.digits' = '2'",
+			)
+		case s.protocolField:
+			props = append(props,
+				fmt.Sprintf("'projection.%s.type' = 'enum'", id),
+				fmt.Sprintf("'projection.%s.values' = '%s'", id, strings.Join(s.protocols, ",")),
+			)
+		default:
+			props = append(props, fmt.Sprintf("'projection.%s.type' = 'injected'", id))
+		}
+		tmpl = append(tmpl, fmt.Sprintf("%s=${%s}", p.Name, id))
+	}
+	props = append(props, fmt.Sprintf("'storage.location.template' = '%s%s/'", location, strings.Join(tmpl, "/")))
+	return props
+}
+
+// writeProperties writes props as the clause of key-value pairs.
+func writeProperties(b *strings.Builder, clause string, props []string) {
+	if len(props) == 0 {
+		return
+	}
+	fmt.Fprintf(b, "%s (\n  %s\n)\n", clause, strings.Join(props, ",\n  "))
+}
+
+// bigQueryField is a field of the BigQuery JSON schema.
+type bigQueryField struct {
+	Name        string          `json:"name"`
+	Type        string          `json:"type"`
+	Mode        string          `json:"mode"`
+	Description string          `json:"description,omitempty"`
+	Fields      []bigQueryField `json:"fields,omitempty"`
+}
+
+// bigQueryFields returns the fields of BigQuery for cs.
+func bigQueryFields(cs []Column) []bigQueryField {
+	fs := []bigQueryField{}
+	for _, c := range cs {
+		mode := "NULLABLE"
+		if c.Type == StringsColumn {
+			mode = "REPEATED"
+		}
+		f := bigQueryField{
+			Name:        c.Identifier(),
+			Type:        c.Type.bigQuery(),
+			Mode:        mode,
+			Description: c.Description,
+		}
+		if c.Type == StructColumn {
+			f.Fields = bigQueryFields(c.Fields)
+		}
+		fs = append(fs, f)
+	}
+	return fs
+}
+
+// BigQuery returns the JSON schema of BigQuery.
+// Partitions are excluded, since they are detected by the hive partitioning of external tables.
+func (s *Schema) BigQuery() ([]byte, error) {
+	b, err := json.MarshalIndent(bigQueryFields(s.dataColumns()), "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("bigquery schema: %w", err)
+	}
+	return b, nil
+}
+
+// jsonSchemaProperty is a property of JSON Schema.
+type jsonSchemaProperty struct {
+	Type        string                        `json:"type,omitempty"`
+	Items       *jsonSchemaProperty           `json:"items,omitempty"`
+	Properties  map[string]jsonSchemaProperty `json:"properties,omitempty"`
+	Description string                        `json:"description,omitempty"`
+}
+
+// jsonSchemaProperties returns the properties of JSON Schema for cs.
+func jsonSchemaProperties(cs []Column) map[string]jsonSchemaProperty {
+	props := make(map[string]jsonSchemaProperty, len(cs))
+	for _, c := range cs {
+		prop := jsonSchemaProperty{Type: c.Type.jsonSchema(), Description: c.Description}
+		switch c.Type {
+		case StringsColumn:
+			prop.Items = &jsonSchemaProperty{Type: StringColumn.jsonSchema()}
+		case StructColumn:
+			prop.Properties = jsonSchemaProperties(c.Fields)
+		}
+		props[c.Name] = prop
+	}
+	return props
+}
+
+// JSONSchema returns the JSON Schema of a log line. Partitions aren't included, since they aren't fields of logs.
+func (s *Schema) JSONSchema() ([]byte, error) {
+	b, err := json.MarshalIndent(struct {
+		Schema     string                        `json:"$schema"`
+		Type       string                        `json:"type"`
+		Properties map[string]jsonSchemaProperty `json:"properties"`
+	}{
+		Schema:     "https://json-schema.org/draft/2020-12/schema",
+		Type:       "object",
+		Properties: jsonSchemaProperties(s.Columns),
+	}, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("json schema: %w", err)
+	}
+	return b, nil
+}
@@ -0,0 +1,236 @@
+package accesslog
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func columnNames(cs []Column) []string {
+	var ns []string
+	for _, c := range cs {
+		ns = append(ns, c.Name)
+	}
+	return ns
+}
+
+func TestHTTPSchema(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []httpOption
+		want []string
+	}{
+		{
+			name: "default",
+			want: []string{"protocol", "path", "status", "ua", "time", "elapsed(ms)", "qs", "panic", "stack"},
+		},
+		{
+			name: "with options",
+			opts: []httpOption{WithHeaders("x-request-id:rid", "user-agent"), WithMethod(), WithClientIP(), WithRequestBody(), WithSampler(RateSampler(0.1))},
+			want: []string{"protocol", "path", "status", "ua", "time", "elapsed(ms)", "qs", "method", "rid", "user-agent", "req_body", "req_body_truncated", "client-ip", "panic", "stack", "sample_rate"},
+		},
+		{
+			name: "v2",
+			opts: []httpOption{WithSchemaVersion(SchemaV2)},
+			want: []string{"protocol", "path", "status", "ua", "time", "elapsed_us", "schema_version", "qs", "panic", "stack"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := columnNames(HTTPSchema(NewDefaultHTTPLogFormatter(tt.opts...)).Columns)
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("HTTPSchema() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGRPCSchema(t *testing.T) {
+	s := GRPCSchema(NewDefaultGRPCLogFormatter(WithMetadata("authority:auth"), WithPeer(), WithRequest()))
+	want := []string{"protocol", "method", "status", "time", "elapsed(ms)", "stream", "msgs_sent", "msgs_recv", "bytes_sent", "bytes_recv", "auth", "peer", "req", "panic", "stack"}
+	if got := columnNames(s.Columns); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("GRPCSchema() = %v, want %v", got, want)
+	}
+}
+
+func TestGRPCSchema_v2(t *testing.T) {
+	s := GRPCSchema(NewDefaultGRPCLogFormatter(WithSchemaVersion(SchemaV2), WithTimeFormat(TimeUnixMilli), WithMetadata("authority")))
+	want := map[string]ColumnType{
+		"status":         IntColumn,
+		"status_name":    StringColumn,
+		"time":           IntColumn,
+		"elapsed_us":     IntColumn,
+		"schema_version": IntColumn,
+		"authority":      StringsColumn,
+	}
+	for _, c := range s.Columns {
+		if typ, ok := want[c.Name]; ok {
+			if c.Type != typ {
+				t.Errorf("type of %s = %v, want %v", c.Name, c.Type, typ)
+			}
+			delete(want, c.Name)
+		}
+	}
+	if len(want) != 0 {
+		t.Errorf("missing columns %v", want)
+	}
+	if ddl := s.AthenaDDL("t", "s3://b/", JSONStorage); !strings.Contains(ddl, "`authority` array<string>") {
+		t.Errorf("AthenaDDL() = %s", ddl)
+	}
+}
+
+func TestColumn_Identifier(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{name: "path", want: "path"},
+		{name: "elapsed(ms)", want: "elapsed_ms"},
+		{name: "client-ip", want: "client_ip"},
+		{name: "X-Request-Id", want: "x_request_id"},
+		{name: ":authority", want: "authority"},
+		{name: "2fa", want: "_2fa"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := (Column{Name: tt.name}).Identifier(); got != tt.want {
+				t.Errorf("Identifier() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSchema_AthenaDDL(t *testing.T) {
+	s := MergeSchemas(
+		HTTPSchema(NewDefaultHTTPLogFormatter(WithClientIP())),
+		GRPCSchema(NewDefaultGRPCLogFormatter()),
+	)
+	ddl := s.AthenaDDL("access_log", "s3://bucket/accesslog", JSONStorage)
+
+	for _, want := range []string{
+		"CREATE EXTERNAL TABLE IF NOT EXISTS `access_log` (",
+		"  `elapsed_ms` double COMMENT",
+		"  `msgs_sent` bigint COMMENT",
+		"  `protocol` string\n)",
+		"'mapping.client_ip' = 'client-ip'",
+		"LOCATION 's3://bucket/accesslog/'",
+		"'projection.protocol.values' = 'http,grpc'",
+		"'storage.location.template' = 's3://bucket/accesslog/dt=${dt}/hour=${hour}/protocol=${protocol}/'",
+	} {
+		if !strings.Contains(ddl, want) {
+			t.Errorf("AthenaDDL() doesn't contain %q\n%s", want, ddl)
+		}
+	}
+	if strings.Contains(ddl, "`protocol` string COMMENT") {
+		t.Errorf("AthenaDDL() must not have the partition as a column\n%s", ddl)
+	}
+}
+
+func TestSchema_AthenaDDL_partitions(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       *Schema
+		want    []string
+		notWant []string
+	}{
+		{
+			name: "naming",
+			s: MergeSchemas(
+				HTTPSchema(NewDefaultHTTPLogFormatter(WithFieldNaming(ECSNaming()))),
+				GRPCSchema(NewDefaultGRPCLogFormatter(WithFieldNaming(ECSNaming()))),
+			),
+			want: []string{
+				"  `network_protocol` string\n)",
+				"'projection.network_protocol.type' = 'enum'",
+				"'projection.network_protocol.values' = 'http,grpc'",
+				"'storage.location.template' = 's3://b/dt=${dt}/hour=${hour}/network.protocol=${network_protocol}/'",
+			},
+			notWant: []string{"`network_protocol` string COMMENT", "projection.protocol"},
+		},
+		{
+			name: "partition field",
+			s:    HTTPSchema(NewDefaultHTTPLogFormatter()).PartitionBy("service"),
+			want: []string{
+				"  `protocol` string COMMENT",
+				"  `service` string\n)",
+				"'projection.service.type' = 'injected'",
+				"'storage.location.template' = 's3://b/dt=${dt}/hour=${hour}/service=${service}/'",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ddl := tt.s.AthenaDDL("t", "s3://b/", JSONStorage)
+			for _, want := range tt.want {
+				if !strings.Contains(ddl, want) {
+					t.Errorf("AthenaDDL() doesn't contain %q\n%s", want, ddl)
+				}
+			}
+			for _, w := range tt.notWant {
+				if strings.Contains(ddl, w) {
+					t.Errorf("AthenaDDL() must not contain %q\n%s", w, ddl)
+				}
+			}
+		})
+	}
+}
+
+func TestSchema_AthenaDDL_parquet(t *testing.T) {
+	s := HTTPSchema(NewDefaultHTTPLogFormatter(WithClientIP()))
+	ddl := s.AthenaDDL("access_log", "s3://bucket/accesslog", ParquetStorage)
+
+	for _, want := range []string{
+		"  `client_ip` string COMMENT",
+		"  `extra` map<string,string> COMMENT",
+		"STORED AS PARQUET\nLOCATION 's3://bucket/accesslog/'",
+	} {
+		if !strings.Contains(ddl, want) {
+			t.Errorf("AthenaDDL() doesn't contain %q\n%s", want, ddl)
+		}
+	}
+	if strings.Contains(ddl, "JsonSerDe") || strings.Contains(ddl, "mapping.") {
+		t.Errorf("AthenaDDL() must not read JSON\n%s", ddl)
+	}
+}
+
+func TestSchema_BigQuery(t *testing.T) {
+	b, err := HTTPSchema(NewDefaultHTTPLogFormatter(WithBytesIn(), WithRequestBody())).BigQuery()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fs []map[string]string
+	if err := json.Unmarshal(b, &fs); err != nil {
+		t.Fatal(err)
+	}
+	types := map[string]string{}
+	for _, f := range fs {
+		types[f["name"]] = f["type"]
+	}
+	if _, ok := types["protocol"]; ok {
+		t.Error("BigQuery() must not have the partition as a field")
+	}
+	if types["elapsed_ms"] != "FLOAT" || types["bytes_in"] != "INTEGER" || types["path"] != "STRING" || types["req_body"] != "JSON" {
+		t.Errorf("BigQuery() = %s", b)
+	}
+}
+
+func TestSchema_JSONSchema(t *testing.T) {
+	b, err := GRPCSchema(NewDefaultGRPCLogFormatter(WithRequest())).JSONSchema()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var s struct {
+		Properties map[string]*struct {
+			Type string `json:"type"`
+		} `json:"properties"`
+	}
+	if err := json.Unmarshal(b, &s); err != nil {
+		t.Fatal(err)
+	}
+	if s.Properties["protocol"].Type != "string" || s.Properties["elapsed(ms)"].Type != "number" || s.Properties["msgs_sent"].Type != "integer" {
+		t.Errorf("JSONSchema() = %s", b)
+	}
+	if req := s.Properties["req"]; req == nil || req.Type != "" {
+		t.Errorf("JSONSchema() = %s", b)
+	}
+}
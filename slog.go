@@ -0,0 +1,195 @@
+package accesslog
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+const defaultSlogHandlerKey = "logs"
+
+// SlogHandler is the slog.Handler routing records logged with a context carrying a LogEntry into the entry,
+// so that application logs during a request are written in its access log instead of separate lines.
+//
+// By default, records are appended to the array "logs" of objects with "time", "level", "msg" and attributes.
+// With SlogHandlerMergeAttrs, attributes are merged into the fields of the entry and messages are dropped.
+// Entries of custom formatters, which can't collect the array, always get attributes merged by LogEntry.Add.
+// Records without a LogEntry, or with a LogEntry not writing fields like TextHTTPLogEntry, are handled
+// by the fallback handler, or dropped if it is nil.
+type SlogHandler struct {
+	fallback slog.Handler
+	level    slog.Leveler
+	key      string
+	merge    bool
+
+	ops []slogOp
+}
+
+// slogOp is a group or attributes added by WithGroup or WithAttrs.
+type slogOp struct {
+	group string
+	attrs []slog.Attr
+}
+
+// NewSlogHandler returns a new SlogHandler.
+func NewSlogHandler(fallback slog.Handler, opts ...slogHandlerOption) *SlogHandler {
+	h := &SlogHandler{
+		fallback: fallback,
+		level:    slog.LevelInfo,
+		key:      defaultSlogHandlerKey,
+	}
+	for _, fn := range opts {
+		fn(h)
+	}
+	return h
+}
+
+// Enabled implements slog.Handler.
+func (h *SlogHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	if h.target(ctx) != nil {
+		return level >= h.level.Level()
+	}
+	return h.fallback != nil && h.fallback.Enabled(ctx, level)
+}
+
+// Handle implements slog.Handler.
+func (h *SlogHandler) Handle(ctx context.Context, r slog.Record) error {
+	le := h.target(ctx)
+	if le == nil {
+		if h.fallback == nil {
+			return nil
+		}
+		return h.fallback.Handle(ctx, r)
+	}
+
+	attrs := make([]slog.Attr, 0, r.NumAttrs())
+	r.Attrs(func(a slog.Attr) bool {
+		attrs = append(attrs, a)
+		return true
+	})
+	attrs = h.apply(attrs)
+
+	if al, ok := le.(appLogger); ok && !h.merge {
+		al.appendLog(h.key, appLog{t: r.Time, level: r.Level, msg: r.Message, attrs: attrs})
+		return nil
+	}
+	addAttrs(le, attrs)
+	return nil
+}
+
+// target returns the LogEntry in ctx the records are routed into, or nil.
+// Entries not writing fields added by LogEntry.Add aren't targets.
+func (h *SlogHandler) target(ctx context.Context) LogEntry {
+	switch le := GetLogEntry(ctx).(type) {
+	case nil, *TextHTTPLogEntry, nopLogEntry:
+		return nil
+	default:
+		return le
+	}
+}
+
+// apply returns attrs in the groups and with the attributes added by WithGroup and WithAttrs.
+func (h *SlogHandler) apply(attrs []slog.Attr) []slog.Attr {
+	for i := len(h.ops) - 1; i >= 0; i-- {
+		op := h.ops[i]
+		if op.group != "" {
+			if len(attrs) == 0 {
+				continue
+			}
+			attrs = []slog.Attr{{Key: op.group, Value: slog.GroupValue(attrs...)}}
+			continue
+		}
+		attrs = append(append([]slog.Attr{}, op.attrs...), attrs...)
+	}
+	return attrs
+}
+
+// WithAttrs implements slog.Handler.
+func (h *SlogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	c := h.with(slogOp{attrs: attrs})
+	if h.fallback != nil {
+		c.fallback = h.fallback.WithAttrs(attrs)
+	}
+	return c
+}
+
+// WithGroup implements slog.Handler.
+func (h *SlogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	c := h.with(slogOp{group: name})
+	if h.fallback != nil {
+		c.fallback = h.fallback.WithGroup(name)
+	}
+	return c
+}
+
+// with returns a copy of h with op.
+func (h *SlogHandler) with(op slogOp) *SlogHandler {
+	c := *h
+	c.ops = append(append(make([]slogOp, 0, len(h.ops)+1), h.ops...), op)
+	return &c
+}
+
+// appLogger is implemented by LogEntry collecting application logs into an array.
+type appLogger interface {
+	appendLog(key string, l appLog)
+}
+
+// appLog is an application log written in a LogEntry.
+type appLog struct {
+	t     time.Time
+	level slog.Level
+	msg   string
+	attrs []slog.Attr
+}
+
+// appLogs collects application logs of a LogEntry by the key of the array.
+type appLogs struct {
+	mu   sync.Mutex
+	logs map[string][]appLog
+}
+
+// append appends l to the array of key. On the first log of key, the function writing the array is added by add.
+func (al *appLogs) append(key string, l appLog, add func(f func(e *zerolog.Event))) {
+	al.mu.Lock()
+	if al.logs == nil {
+		al.logs = map[string][]appLog{}
+	}
+	_, ok := al.logs[key]
+	al.logs[key] = append(al.logs[key], l)
+	al.mu.Unlock()
+
+	// add is called without the lock, since entries call the added functions with their own locks held.
+	if !ok {
+		add(func(e *zerolog.Event) {
+			al.write(e, key)
+		})
+	}
+}
+
+// write writes the array of key into e.
+func (al *appLogs) write(e *zerolog.Event, key string) {
+	al.mu.Lock()
+	defer al.mu.Unlock()
+
+	arr := zerolog.Arr()
+	for _, l := range al.logs[key] {
+		d := zerolog.Dict().
+			Str("time", l.t.UTC().Format(time.RFC3339Nano)).
+			Str("level", l.level.String()).
+			Str("msg", l.msg)
+		for _, a := range l.attrs {
+			writeAttr(d, a)
+		}
+		arr.Dict(d)
+	}
+	e.Array(key, arr)
+}
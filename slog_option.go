@@ -0,0 +1,27 @@
+package accesslog
+
+import "log/slog"
+
+type slogHandlerOption func(h *SlogHandler)
+
+// SlogHandlerLevel specifies the minimum level of records routed into LogEntry. The default is slog.LevelInfo.
+func SlogHandlerLevel(l slog.Leveler) slogHandlerOption {
+	return func(h *SlogHandler) {
+		h.level = l
+	}
+}
+
+// SlogHandlerKey specifies the key of the array of records in LogEntry. The default is "logs".
+func SlogHandlerKey(key string) slogHandlerOption {
+	return func(h *SlogHandler) {
+		h.key = key
+	}
+}
+
+// SlogHandlerMergeAttrs specifies that attributes of records are merged into the fields of LogEntry
+// instead of the array, and messages are dropped.
+func SlogHandlerMergeAttrs() slogHandlerOption {
+	return func(h *SlogHandler) {
+		h.merge = true
+	}
+}
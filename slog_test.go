@@ -0,0 +1,121 @@
+package accesslog
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// customLogEntry is the LogEntry of a custom formatter.
+type customLogEntry struct {
+	add []func(e *zerolog.Event)
+}
+
+func (le *customLogEntry) Write(t time.Time) {}
+
+func (le *customLogEntry) Add(f func(e *zerolog.Event)) {
+	le.add = append(le.add, f)
+}
+
+func TestSlogHandler(t *testing.T) {
+	now := time.Date(2021, 12, 9, 2, 39, 46, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		opts     []slogHandlerOption
+		entry    LogEntry
+		want     string
+		fallback string
+	}{
+		{
+			name:  "logs",
+			entry: &DefaultGRPCClientLogEntry{},
+			want:  `{"logs":[{"time":"2021-12-09T02:39:46Z","level":"INFO","msg":"hello","svc":"a","g":{"n":1}},{"time":"2021-12-09T02:39:46Z","level":"WARN","msg":"bye","svc":"a"}]}` + "\n",
+		},
+		{
+			name:  "merged",
+			opts:  []slogHandlerOption{SlogHandlerMergeAttrs()},
+			entry: &DefaultGRPCClientLogEntry{},
+			want:  `{"svc":"a","g":{"n":1},"svc":"a"}` + "\n",
+		},
+		{
+			name:  "custom entry",
+			entry: &customLogEntry{},
+			want:  `{"svc":"a","g":{"n":1},"svc":"a"}` + "\n",
+		},
+		{
+			name:     "no entry",
+			fallback: `level=INFO msg=hello svc=a g.n=1` + "\n" + `level=WARN msg=bye svc=a` + "\n",
+		},
+		{
+			name:     "text entry",
+			entry:    &TextHTTPLogEntry{},
+			fallback: `level=INFO msg=hello svc=a g.n=1` + "\n" + `level=WARN msg=bye svc=a` + "\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var fb bytes.Buffer
+			fallback := slog.NewTextHandler(&fb, &slog.HandlerOptions{
+				ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+					if a.Key == slog.TimeKey && len(groups) == 0 {
+						return slog.Attr{}
+					}
+					return a
+				},
+			})
+			h := NewSlogHandler(fallback, tt.opts...).WithAttrs([]slog.Attr{slog.String("svc", "a")})
+
+			ctx := context.Background()
+			if tt.entry != nil {
+				ctx = SetLogEntry(ctx, tt.entry)
+			}
+			for _, r := range []struct {
+				h     slog.Handler
+				level slog.Level
+				msg   string
+				attrs []slog.Attr
+			}{
+				{h: h.WithGroup("g"), level: slog.LevelInfo, msg: "hello", attrs: []slog.Attr{slog.Int("n", 1)}},
+				{h: h, level: slog.LevelDebug, msg: "ignored"},
+				{h: h, level: slog.LevelWarn, msg: "bye"},
+			} {
+				if !r.h.Enabled(ctx, r.level) {
+					continue
+				}
+				rec := slog.NewRecord(now, r.level, r.msg, 0)
+				rec.AddAttrs(r.attrs...)
+				if err := r.h.Handle(ctx, rec); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			var add []func(e *zerolog.Event)
+			switch le := tt.entry.(type) {
+			case *DefaultGRPCClientLogEntry:
+				add = le.add
+			case *customLogEntry:
+				add = le.add
+			}
+			if add != nil {
+				var buf bytes.Buffer
+				l := zerolog.New(&buf)
+				e := l.Log()
+				for _, f := range add {
+					f(e)
+				}
+				e.Send()
+				if got := buf.String(); got != tt.want {
+					t.Errorf("got %s, want %s", got, tt.want)
+				}
+			}
+			if got := fb.String(); got != tt.fallback {
+				t.Errorf("fallback got %s, want %s", got, tt.fallback)
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package accesslog
+
+import (
+	"context"
+	"encoding/hex"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/metadata"
+)
+
+// traceContext is the trace context of a request, to join its log to traces.
+type traceContext struct {
+	traceID string
+	spanID  string
+	flags   string
+}
+
+// traceFromContext returns the trace context of the OpenTelemetry span in ctx,
+// or, failing that, the one propagated in W3C traceparent or B3 headers got by get.
+func traceFromContext(ctx context.Context, get func(key string) string) traceContext {
+	if ctx != nil {
+		if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+			return traceContext{traceID: sc.TraceID().String(), spanID: sc.SpanID().String(), flags: sc.TraceFlags().String()}
+		}
+	}
+	if tc := parseTraceparent(get("traceparent")); tc.traceID != "" {
+		return tc
+	}
+	if tc := parseB3(get("b3")); tc.traceID != "" {
+		return tc
+	}
+	return parseB3Multi(get("x-b3-traceid"), get("x-b3-spanid"), get("x-b3-sampled"), get("x-b3-flags"))
+}
+
+// metadataGetter returns the function getting the first value of the key in md.
+func metadataGetter(md metadata.MD) func(key string) string {
+	return func(key string) string {
+		if vs := md.Get(key); len(vs) != 0 {
+			return vs[0]
+		}
+		return ""
+	}
+}
+
+// parseTraceparent parses the W3C traceparent header.
+// e.g. "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+func parseTraceparent(tp string) traceContext {
+	ps := strings.Split(strings.TrimSpace(tp), "-")
+	if len(ps) < 4 || !isHexID(ps[1], 32) || !isHexID(ps[2], 16) || !isHexID(ps[3], 2) {
+		return traceContext{}
+	}
+	return traceContext{traceID: ps[1], spanID: ps[2], flags: ps[3]}
+}
+
+// parseB3 parses the B3 single header, {TraceId}-{SpanId}-{SamplingState}-{ParentSpanId}.
+// 64-bit trace IDs are padded to 128 bits.
+func parseB3(b3 string) traceContext {
+	ps := strings.Split(strings.TrimSpace(b3), "-")
+	if len(ps) < 2 {
+		return traceContext{}
+	}
+	var sampled string
+	if len(ps) > 2 {
+		sampled = ps[2]
+	}
+	return parseB3Multi(ps[0], ps[1], sampled, "")
+}
+
+// parseB3Multi parses the B3 multiple headers, X-B3-TraceId, X-B3-SpanId, X-B3-Sampled and X-B3-Flags.
+func parseB3Multi(traceID, spanID, sampled, flags string) traceContext {
+	traceID, spanID = strings.ToLower(strings.TrimSpace(traceID)), strings.ToLower(strings.TrimSpace(spanID))
+	if isHexID(traceID, 16) {
+		traceID = strings.Repeat("0", 16) + traceID
+	}
+	if !isHexID(traceID, 32) || !isHexID(spanID, 16) {
+		return traceContext{}
+	}
+
+	tc := traceContext{traceID: traceID, spanID: spanID, flags: "00"}
+	switch strings.TrimSpace(sampled) {
+	case "1", "true", "d":
+		tc.flags = "01"
+	}
+	if strings.TrimSpace(flags) == "1" {
+		tc.flags = "01"
+	}
+	return tc
+}
+
+// isHexID reports whether s is the lowercase hex of n characters, and not all zeros if n is more than 2.
+func isHexID(s string, n int) bool {
+	if len(s) != n || s != strings.ToLower(s) {
+		return false
+	}
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return false
+	}
+	if n <= 2 {
+		return true
+	}
+	for _, c := range b {
+		if c != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// writeTrace writes the trace context if WithTrace is set.
+func (cfg *commonConfig) writeTrace(f *fields, tc traceContext) {
+	if !cfg.withTrace || tc.traceID == "" {
+		return
+	}
+	f.Str("trace_id", tc.traceID).
+		Str("span_id", tc.spanID).
+		Str("trace_flags", tc.flags)
+}
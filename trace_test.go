@@ -0,0 +1,112 @@
+package accesslog
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/metadata"
+)
+
+func Test_traceFromContext(t *testing.T) {
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36},
+		SpanID:     trace.SpanID{0x00, 0xf0, 0x67, 0xaa, 0x0b, 0xa9, 0x02, 0xb7},
+		TraceFlags: trace.FlagsSampled,
+	})
+
+	tests := []struct {
+		name   string
+		ctx    context.Context
+		header http.Header
+		want   traceContext
+	}{
+		{
+			name:   "span context",
+			ctx:    trace.ContextWithSpanContext(context.Background(), sc),
+			header: http.Header{"Traceparent": {"00-11111111111111111111111111111111-2222222222222222-00"}},
+			want:   traceContext{traceID: "4bf92f3577b34da6a3ce929d0e0e4736", spanID: "00f067aa0ba902b7", flags: "01"},
+		},
+		{
+			name:   "traceparent",
+			ctx:    context.Background(),
+			header: http.Header{"Traceparent": {"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"}},
+			want:   traceContext{traceID: "4bf92f3577b34da6a3ce929d0e0e4736", spanID: "00f067aa0ba902b7", flags: "01"},
+		},
+		{
+			name:   "invalid traceparent falls back to b3",
+			ctx:    context.Background(),
+			header: http.Header{"Traceparent": {"00-00000000000000000000000000000000-00f067aa0ba902b7-01"}, "B3": {"a3ce929d0e0e4736-00f067aa0ba902b7-d"}},
+			want:   traceContext{traceID: "0000000000000000a3ce929d0e0e4736", spanID: "00f067aa0ba902b7", flags: "01"},
+		},
+		{
+			name: "b3 multi",
+			ctx:  context.Background(),
+			header: http.Header{
+				"X-B3-Traceid": {"4bf92f3577b34da6a3ce929d0e0e4736"},
+				"X-B3-Spanid":  {"00f067aa0ba902b7"},
+				"X-B3-Sampled": {"0"},
+			},
+			want: traceContext{traceID: "4bf92f3577b34da6a3ce929d0e0e4736", spanID: "00f067aa0ba902b7", flags: "00"},
+		},
+		{
+			name:   "none",
+			ctx:    context.Background(),
+			header: http.Header{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := traceFromContext(tt.ctx, tt.header.Get); got != tt.want {
+				t.Errorf("traceFromContext() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_metadataGetter(t *testing.T) {
+	md := metadata.Pairs("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	tc := traceFromContext(context.Background(), metadataGetter(md))
+	if tc.traceID != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("traceFromContext() = %+v", tc)
+	}
+}
+
+func TestCommonConfig_writeTrace(t *testing.T) {
+	tc := traceContext{traceID: "4bf92f3577b34da6a3ce929d0e0e4736", spanID: "00f067aa0ba902b7", flags: "01"}
+	tests := []struct {
+		name string
+		cfg  commonConfig
+		want string
+	}{
+		{
+			name: "disabled",
+			want: `{}`,
+		},
+		{
+			name: "enabled",
+			cfg:  commonConfig{withTrace: true},
+			want: `{"trace_id":"4bf92f3577b34da6a3ce929d0e0e4736","span_id":"00f067aa0ba902b7","trace_flags":"01"}`,
+		},
+		{
+			name: "ecs",
+			cfg:  commonConfig{withTrace: true, naming: ECSNaming()},
+			want: `{"trace.id":"4bf92f3577b34da6a3ce929d0e0e4736","span.id":"00f067aa0ba902b7","trace_flags":"01"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			l := zerolog.New(&buf)
+			f := tt.cfg.fields(l.Log(), "http")
+			tt.cfg.writeTrace(f, tc)
+			f.Event().Send()
+			if got := buf.String(); got != tt.want+"\n" {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
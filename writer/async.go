@@ -0,0 +1,300 @@
+package writer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	defaultAsyncBufferSize    = 8192
+	defaultAsyncBatchSize     = 128
+	defaultAsyncFlushInterval = time.Second
+)
+
+// ErrClosed is returned when writing to a closed writer.
+var ErrClosed = errors.New("writer closed")
+
+// OverflowPolicy is the policy of AsyncLogWriter when its buffer is full.
+type OverflowPolicy int
+
+const (
+	// OverflowBlock blocks writing until the buffer has room.
+	// A blocked write fails with ErrClosed and is counted as dropped if the writer is closed.
+	OverflowBlock OverflowPolicy = iota
+	// OverflowDropNewest drops the log being written.
+	OverflowDropNewest
+	// OverflowDropOldest drops the oldest log in the buffer.
+	OverflowDropOldest
+)
+
+// AsyncStats is the statistics of AsyncLogWriter.
+type AsyncStats struct {
+	// Written is the number of logs written to the underlying writer.
+	Written uint64
+	// Dropped is the number of logs dropped by the overflow policy, rejected after closed or failed to be written.
+	Dropped uint64
+}
+
+// BatchWriter is implemented by writers accepting newline delimited logs at once.
+// AsyncLogWriter writes each batch to them by a single WriteBatch call, and each log by a Write call to the other writers.
+type BatchWriter interface {
+	io.Writer
+	WriteBatch(logs []byte) error
+}
+
+// AsyncLogWriter is the log writer that implements io.Writer.
+// It buffers logs in a bounded ring buffer, and writes them to the underlying writer in batches on a background goroutine.
+// A batch is written by a single call if the underlying writer implements BatchWriter, or by a Write call per log.
+type AsyncLogWriter struct {
+	// written and dropped are accessed atomically, and placed first to be 64-bit aligned.
+	written uint64
+	dropped uint64
+
+	w        io.Writer
+	size     int
+	policy   OverflowPolicy
+	batch    int
+	interval time.Duration
+	onError  func(err error)
+
+	mu       sync.Mutex
+	notFull  *sync.Cond
+	ring     [][]byte
+	head     int
+	n        int
+	enqueued uint64
+	done     uint64
+	progress chan struct{}
+	closed   bool
+
+	wake    chan struct{}
+	stopped chan struct{}
+}
+
+// NewAsyncLogWriter creates a new AsyncLogWriter writing to w.
+func NewAsyncLogWriter(w io.Writer, opts ...asyncOption) *AsyncLogWriter {
+	aw := &AsyncLogWriter{
+		w:        w,
+		size:     defaultAsyncBufferSize,
+		batch:    defaultAsyncBatchSize,
+		interval: defaultAsyncFlushInterval,
+		progress: make(chan struct{}),
+		wake:     make(chan struct{}, 1),
+		stopped:  make(chan struct{}),
+	}
+	for _, fn := range opts {
+		fn(aw)
+	}
+	if aw.batch > aw.size {
+		aw.batch = aw.size
+	}
+	aw.ring = make([][]byte, aw.size)
+	aw.notFull = sync.NewCond(&aw.mu)
+
+	go aw.run()
+
+	return aw
+}
+
+// Write buffers a log. p is copied, since loggers may reuse it.
+func (w *AsyncLogWriter) Write(p []byte) (n int, err error) {
+	b := make([]byte, len(p), len(p)+1)
+	copy(b, p)
+	if len(b) == 0 || b[len(b)-1] != '\n' {
+		b = append(b, '\n')
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for w.n == w.size && !w.closed {
+		switch w.policy {
+		case OverflowDropNewest:
+			atomic.AddUint64(&w.dropped, 1)
+			return len(p), nil
+		case OverflowDropOldest:
+			w.ring[w.head] = nil
+			w.head = (w.head + 1) % w.size
+			w.n--
+			w.done++
+			w.progressLocked()
+			atomic.AddUint64(&w.dropped, 1)
+		default:
+			w.notFull.Wait()
+		}
+	}
+	if w.closed {
+		atomic.AddUint64(&w.dropped, 1)
+		return 0, fmt.Errorf("async log writer write: %w", ErrClosed)
+	}
+
+	w.ring[(w.head+w.n)%w.size] = b
+	w.n++
+	w.enqueued++
+	if w.n >= w.batch {
+		w.signalLocked()
+	}
+
+	return len(p), nil
+}
+
+// Flush writes all logs buffered before calling Flush to the underlying writer.
+// It returns the error of ctx if ctx is done before flushed.
+func (w *AsyncLogWriter) Flush(ctx context.Context) error {
+	w.mu.Lock()
+	target := w.enqueued
+	w.signalLocked()
+	w.mu.Unlock()
+
+	if err := w.wait(ctx, target); err != nil {
+		return fmt.Errorf("flush async log writer: %w", err)
+	}
+	return nil
+}
+
+// Close flushes all buffered logs and stops the background goroutine.
+// It returns the error of ctx if ctx is done before flushed. The underlying writer isn't closed.
+func (w *AsyncLogWriter) Close(ctx context.Context) error {
+	w.mu.Lock()
+	if !w.closed {
+		w.closed = true
+		close(w.wake)
+	}
+	w.notFull.Broadcast()
+	w.mu.Unlock()
+
+	select {
+	case <-w.stopped:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("close async log writer: %w", ctx.Err())
+	}
+}
+
+// Stats returns the statistics of the writer.
+func (w *AsyncLogWriter) Stats() AsyncStats {
+	return AsyncStats{
+		Written: atomic.LoadUint64(&w.written),
+		Dropped: atomic.LoadUint64(&w.dropped),
+	}
+}
+
+// signalLocked wakes the background goroutine up without blocking. w.mu must be held.
+func (w *AsyncLogWriter) signalLocked() {
+	if w.closed {
+		return
+	}
+	select {
+	case w.wake <- struct{}{}:
+	default:
+	}
+}
+
+// progressLocked wakes up the waiters of progress after done advances. w.mu must be held.
+func (w *AsyncLogWriter) progressLocked() {
+	close(w.progress)
+	w.progress = make(chan struct{})
+}
+
+// wait waits until the logs up to target are done.
+func (w *AsyncLogWriter) wait(ctx context.Context, target uint64) error {
+	for {
+		w.mu.Lock()
+		done, progress := w.done, w.progress
+		w.mu.Unlock()
+		if done >= target {
+			return nil
+		}
+
+		select {
+		case <-progress:
+		case <-w.stopped:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// run writes buffered logs periodically or when woken up until closed.
+func (w *AsyncLogWriter) run() {
+	defer close(w.stopped)
+
+	t := time.NewTicker(w.interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case _, ok := <-w.wake:
+			w.drain()
+			if !ok {
+				return
+			}
+		case <-t.C:
+			w.drain()
+		}
+	}
+}
+
+// drain writes all buffered logs in batches.
+func (w *AsyncLogWriter) drain() {
+	lines := make([][]byte, 0, w.batch)
+	for {
+		w.mu.Lock()
+		k := w.n
+		if k > w.batch {
+			k = w.batch
+		}
+		lines = lines[:0]
+		for i := 0; i < k; i++ {
+			idx := (w.head + i) % w.size
+			lines = append(lines, w.ring[idx])
+			w.ring[idx] = nil
+		}
+		w.head = (w.head + k) % w.size
+		w.n -= k
+		w.notFull.Broadcast()
+		w.mu.Unlock()
+
+		if k == 0 {
+			return
+		}
+
+		w.writeBatch(lines)
+
+		w.mu.Lock()
+		w.done += uint64(k)
+		w.progressLocked()
+		w.mu.Unlock()
+	}
+}
+
+// writeBatch writes lines to the underlying writer, at once if it implements BatchWriter.
+func (w *AsyncLogWriter) writeBatch(lines [][]byte) {
+	if bw, ok := w.w.(BatchWriter); ok {
+		w.record(len(lines), bw.WriteBatch(bytes.Join(lines, nil)))
+		return
+	}
+	for _, l := range lines {
+		_, err := w.w.Write(l)
+		w.record(1, err)
+	}
+}
+
+// record counts n logs written to the underlying writer, or dropped if err isn't nil.
+func (w *AsyncLogWriter) record(n int, err error) {
+	if err != nil {
+		atomic.AddUint64(&w.dropped, uint64(n))
+		if w.onError != nil {
+			w.onError(fmt.Errorf("async log writer write: %w", err))
+		}
+		return
+	}
+	atomic.AddUint64(&w.written, uint64(n))
+}
@@ -0,0 +1,47 @@
+package writer
+
+import "time"
+
+type asyncOption func(w *AsyncLogWriter)
+
+// AsyncBufferSize specifies the number of logs the buffer can hold. The default is 8192.
+func AsyncBufferSize(n int) asyncOption {
+	return func(w *AsyncLogWriter) {
+		if n > 0 {
+			w.size = n
+		}
+	}
+}
+
+// AsyncOverflowPolicy specifies the policy when the buffer is full. The default is OverflowBlock.
+func AsyncOverflowPolicy(p OverflowPolicy) asyncOption {
+	return func(w *AsyncLogWriter) {
+		w.policy = p
+	}
+}
+
+// AsyncBatchSize specifies the maximum number of logs written at once. The default is 128.
+// The buffered logs are written as soon as the number of them reaches n.
+func AsyncBatchSize(n int) asyncOption {
+	return func(w *AsyncLogWriter) {
+		if n > 0 {
+			w.batch = n
+		}
+	}
+}
+
+// AsyncFlushInterval specifies the interval to write buffered logs. The default is 1s.
+func AsyncFlushInterval(d time.Duration) asyncOption {
+	return func(w *AsyncLogWriter) {
+		if d > 0 {
+			w.interval = d
+		}
+	}
+}
+
+// AsyncErrorHandler specifies the function called when the underlying writer fails to write.
+func AsyncErrorHandler(fn func(err error)) asyncOption {
+	return func(w *AsyncLogWriter) {
+		w.onError = fn
+	}
+}
@@ -0,0 +1,282 @@
+package writer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// blockingWriter blocks writing until unblocked.
+type blockingWriter struct {
+	mu      sync.Mutex
+	buf     bytes.Buffer
+	unblock chan struct{}
+}
+
+func (w *blockingWriter) Write(p []byte) (int, error) {
+	<-w.unblock
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.Write(p)
+}
+
+func (w *blockingWriter) String() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.String()
+}
+
+func TestAsyncLogWriter_Flush(t *testing.T) {
+	bw := &blockingWriter{unblock: make(chan struct{})}
+	close(bw.unblock)
+	w := NewAsyncLogWriter(bw, AsyncBatchSize(2), AsyncFlushInterval(time.Hour))
+
+	for i := 0; i < 5; i++ {
+		if _, err := w.Write([]byte(strconv.Itoa(i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := w.Flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := bw.String(), "0\n1\n2\n3\n4\n"; got != want {
+		t.Errorf("written = %q, want %q", got, want)
+	}
+	if got, want := w.Stats(), (AsyncStats{Written: 5}); got != want {
+		t.Errorf("Stats() = %+v, want %+v", got, want)
+	}
+
+	if err := w.Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte("5")); !errors.Is(err, ErrClosed) {
+		t.Errorf("Write() after Close() error = %v, want %v", err, ErrClosed)
+	}
+}
+
+func TestAsyncLogWriter_overflow(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy OverflowPolicy
+		want   string
+	}{
+		{
+			name:   "drop newest",
+			policy: OverflowDropNewest,
+			want:   "0\n1\n2\n3\n",
+		},
+		{
+			name:   "drop oldest",
+			policy: OverflowDropOldest,
+			want:   "0\n1\n3\n4\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bw := &blockingWriter{unblock: make(chan struct{})}
+			w := NewAsyncLogWriter(bw,
+				AsyncBufferSize(2),
+				AsyncBatchSize(2),
+				AsyncFlushInterval(time.Hour),
+				AsyncOverflowPolicy(tt.policy),
+			)
+			write := func(i int) {
+				if _, err := w.Write([]byte(strconv.Itoa(i))); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			// the first batch is taken by the background goroutine blocked in writing.
+			write(0)
+			write(1)
+			for {
+				w.mu.Lock()
+				n := w.n
+				w.mu.Unlock()
+				if n == 0 {
+					break
+				}
+				time.Sleep(time.Millisecond)
+			}
+			write(2)
+			write(3)
+			write(4)
+			close(bw.unblock)
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			if err := w.Close(ctx); err != nil {
+				t.Fatal(err)
+			}
+			if got := bw.String(); got != tt.want {
+				t.Errorf("written = %q, want %q", got, tt.want)
+			}
+			if got, want := w.Stats(), (AsyncStats{Written: 4, Dropped: 1}); got != want {
+				t.Errorf("Stats() = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestAsyncLogWriter_closeBlocked(t *testing.T) {
+	bw := &blockingWriter{unblock: make(chan struct{})}
+	w := NewAsyncLogWriter(bw, AsyncBufferSize(1), AsyncBatchSize(1), AsyncFlushInterval(time.Hour))
+
+	// the first log is taken by the background goroutine blocked in writing, and the second fills the buffer.
+	if _, err := w.Write([]byte("0")); err != nil {
+		t.Fatal(err)
+	}
+	for {
+		w.mu.Lock()
+		n := w.n
+		w.mu.Unlock()
+		if n == 0 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if _, err := w.Write([]byte("1")); err != nil {
+		t.Fatal(err)
+	}
+
+	errc := make(chan error, 1)
+	go func() {
+		_, err := w.Write([]byte("2"))
+		errc <- err
+	}()
+	// wait for the write to be blocked on the full buffer.
+	time.Sleep(10 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	closed := make(chan error, 1)
+	go func() { closed <- w.Close(ctx) }()
+
+	if err := <-errc; !errors.Is(err, ErrClosed) {
+		t.Errorf("blocked Write() error = %v, want %v", err, ErrClosed)
+	}
+	close(bw.unblock)
+	if err := <-closed; err != nil {
+		t.Fatal(err)
+	}
+	if got, want := bw.String(), "0\n1\n"; got != want {
+		t.Errorf("written = %q, want %q", got, want)
+	}
+	if got, want := w.Stats(), (AsyncStats{Written: 2, Dropped: 1}); got != want {
+		t.Errorf("Stats() = %+v, want %+v", got, want)
+	}
+}
+
+// lineWriter rejects writes of multiple lines like FluentLogWriter.
+type lineWriter struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	if bytes.Count(p, []byte("\n")) != 1 {
+		return 0, errors.New("multiple lines")
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.lines = append(w.lines, string(p))
+	return len(p), nil
+}
+
+// batchWriter is the lineWriter accepting batches by WriteBatch.
+type batchWriter struct {
+	lineWriter
+	batches int
+}
+
+func (w *batchWriter) WriteBatch(logs []byte) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.batches++
+	for _, l := range bytes.SplitAfter(logs, []byte("\n")) {
+		if len(l) != 0 {
+			w.lines = append(w.lines, string(l))
+		}
+	}
+	return nil
+}
+
+func TestAsyncLogWriter_batch(t *testing.T) {
+	lw := &lineWriter{}
+	bw := &batchWriter{}
+	for _, uw := range []io.Writer{lw, bw} {
+		w := NewAsyncLogWriter(uw, AsyncBatchSize(3), AsyncFlushInterval(time.Hour))
+		for i := 0; i < 3; i++ {
+			if _, err := w.Write([]byte(strconv.Itoa(i))); err != nil {
+				t.Fatal(err)
+			}
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		if err := w.Close(ctx); err != nil {
+			t.Fatal(err)
+		}
+		cancel()
+		if got, want := w.Stats(), (AsyncStats{Written: 3}); got != want {
+			t.Errorf("Stats() of %T = %+v, want %+v", uw, got, want)
+		}
+	}
+	if got, want := strings.Join(lw.lines, ""), "0\n1\n2\n"; got != want {
+		t.Errorf("written = %q, want %q", got, want)
+	}
+	if got, want := strings.Join(bw.lines, ""), "0\n1\n2\n"; got != want || bw.batches != 1 {
+		t.Errorf("written = %q in %d batches, want %q in a batch", got, bw.batches, want)
+	}
+}
+
+func TestAsyncLogWriter_flushDropped(t *testing.T) {
+	bw := &blockingWriter{unblock: make(chan struct{})}
+	defer close(bw.unblock)
+	w := NewAsyncLogWriter(bw,
+		AsyncBufferSize(2),
+		AsyncBatchSize(1),
+		AsyncFlushInterval(time.Hour),
+		AsyncOverflowPolicy(OverflowDropOldest),
+	)
+	write := func(i int) {
+		if _, err := w.Write([]byte(strconv.Itoa(i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the first log is taken by the background goroutine blocked in writing, and the others fill the buffer.
+	write(0)
+	for {
+		w.mu.Lock()
+		n := w.n
+		w.mu.Unlock()
+		if n == 0 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	write(1)
+	write(2)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	flushed := make(chan error, 1)
+	go func() { flushed <- w.Flush(ctx) }()
+	time.Sleep(10 * time.Millisecond)
+
+	// the logs up to the target of Flush are done by dropping, while the first one is still being written.
+	for i := 3; i < 6; i++ {
+		write(i)
+	}
+	if err := <-flushed; err != nil {
+		t.Errorf("Flush() = %v, want flushed by dropping", err)
+	}
+}
@@ -0,0 +1,22 @@
+package writer
+
+import (
+	"bytes"
+)
+
+const (
+	defaultBackendMessage = "access log"
+	defaultBackendKey     = "access_log"
+)
+
+// eachLog calls fn with each line in p, and whether the line is a JSON object written by formatters.
+// Lines aren't decoded, since they are logged as raw JSON.
+func eachLog(p []byte, fn func(line []byte, isJSON bool)) {
+	for _, line := range bytes.Split(p, []byte{'\n'}) {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		fn(line, line[0] == '{' && line[len(line)-1] == '}')
+	}
+}
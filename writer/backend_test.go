@@ -0,0 +1,75 @@
+package writer
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestBackendLogWriters(t *testing.T) {
+	in := `{"protocol":"http","status":200,"elapsed(ms)":1.5,"sampled":true,"md":["a","b"],"httpRequest":{"status":200}}` + "\n" + "plain line\n"
+
+	tests := []struct {
+		name string
+		new  func(buf *bytes.Buffer) io.Writer
+		want []string
+	}{
+		{
+			name: "slog",
+			new: func(buf *bytes.Buffer) io.Writer {
+				h := slog.NewJSONHandler(buf, &slog.HandlerOptions{
+					ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+						if a.Key == slog.TimeKey && len(groups) == 0 {
+							return slog.Attr{}
+						}
+						return a
+					},
+				})
+				return NewSlogLogWriter(slog.New(h))
+			},
+			want: []string{
+				`{"level":"INFO","msg":"access log","access_log":{"protocol":"http","status":200,"elapsed(ms)":1.5,"sampled":true,"md":["a","b"],"httpRequest":{"status":200}}}`,
+				`{"level":"INFO","msg":"plain line"}`,
+			},
+		},
+		{
+			name: "zap",
+			new: func(buf *bytes.Buffer) io.Writer {
+				enc := zapcore.NewJSONEncoder(zapcore.EncoderConfig{MessageKey: "msg", LevelKey: "level", EncodeLevel: zapcore.LowercaseLevelEncoder})
+				return NewZapLogWriter(zap.New(zapcore.NewCore(enc, zapcore.AddSync(buf), zapcore.DebugLevel)), ZapMessage("access"), ZapKey("log"))
+			},
+			want: []string{
+				`{"level":"info","msg":"access","log":{"protocol":"http","status":200,"elapsed(ms)":1.5,"sampled":true,"md":["a","b"],"httpRequest":{"status":200}}}`,
+				`{"level":"info","msg":"plain line"}`,
+			},
+		},
+		{
+			name: "zerolog",
+			new: func(buf *bytes.Buffer) io.Writer {
+				return NewZerologLogWriter(zerolog.New(buf).With().Str("service", "svc").Logger(), ZerologLevel(zerolog.WarnLevel))
+			},
+			want: []string{
+				`{"level":"warn","service":"svc","access_log":{"protocol":"http","status":200,"elapsed(ms)":1.5,"sampled":true,"md":["a","b"],"httpRequest":{"status":200}},"message":"access log"}`,
+				`{"level":"warn","service":"svc","message":"plain line"}`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			w := tt.new(&buf)
+			if n, err := w.Write([]byte(in)); n != len(in) || err != nil {
+				t.Fatalf("Write() = %v, %v", n, err)
+			}
+			if got, want := buf.String(), strings.Join(tt.want, "\n")+"\n"; got != want {
+				t.Errorf("got %s, want %s", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package writer
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Encoder encodes newline delimited JSON logs into the format of objects or rotated files.
+type Encoder interface {
+	// Encode writes logs encoded into w.
+	Encode(w io.Writer, logs []byte) error
+	// Ext returns the extension of encoded files, e.g. ".json.gz".
+	Ext() string
+}
+
+// StreamEncoder is the Encoder encoding logs read from a reader, not to load whole rotated files into memory.
+type StreamEncoder interface {
+	Encoder
+	// EncodeStream writes logs read from r encoded into w.
+	EncodeStream(w io.Writer, r io.Reader) error
+}
+
+// Compression is the compression of JSON lines encoded by JSONEncoder.
+type Compression int
+
+const (
+	// CompressionGzip compresses logs with gzip.
+	CompressionGzip Compression = iota
+	// CompressionZstd compresses logs with zstd.
+	CompressionZstd
+	// CompressionNone doesn't compress logs.
+	CompressionNone
+)
+
+// ext returns the extension of files compressed by c.
+func (c Compression) ext() string {
+	switch c {
+	case CompressionGzip:
+		return ".gz"
+	case CompressionZstd:
+		return ".zst"
+	default:
+		return ""
+	}
+}
+
+// JSONEncoder is the Encoder that encodes logs into JSON lines as they are, compressed.
+type JSONEncoder struct {
+	compression Compression
+}
+
+// NewJSONEncoder returns a new JSONEncoder compressing logs with c.
+func NewJSONEncoder(c Compression) *JSONEncoder {
+	return &JSONEncoder{compression: c}
+}
+
+// Encode implements Encoder.
+func (enc *JSONEncoder) Encode(w io.Writer, logs []byte) error {
+	return enc.EncodeStream(w, bytes.NewReader(logs))
+}
+
+// EncodeStream implements StreamEncoder.
+func (enc *JSONEncoder) EncodeStream(w io.Writer, r io.Reader) error {
+	switch enc.compression {
+	case CompressionGzip:
+		gz := gzip.NewWriter(w)
+		if _, err := io.Copy(gz, r); err != nil {
+			return err
+		}
+		return gz.Close()
+	case CompressionZstd:
+		zw, err := zstd.NewWriter(w)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(zw, r); err != nil {
+			zw.Close()
+			return err
+		}
+		return zw.Close()
+	default:
+		_, err := io.Copy(w, r)
+		return err
+	}
+}
+
+// Ext implements Encoder.
+func (enc *JSONEncoder) Ext() string {
+	return ".json" + enc.compression.ext()
+}
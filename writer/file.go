@@ -0,0 +1,415 @@
+package writer
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	backupTimeFormat = "2006-01-02T15-04-05.000"
+	compressSuffix   = ".gz"
+)
+
+// FileLogWriter is the log writer that implements io.Writer.
+// It writes logs to a local file, and rotates the file by size and/or time window.
+// Rotated files are named with the rotation time like "access-2021-12-09T02-39-46.026.log",
+// and compressed with gzip or encoded by the encoder in the background.
+// It is safe for concurrent use, so the HTTP and gRPC loggers can share it.
+type FileLogWriter struct {
+	filename   string
+	maxSize    int64
+	interval   time.Duration
+	maxAge     time.Duration
+	maxBackups int
+	compress   bool
+	encoder    Encoder
+	now        func() time.Time
+
+	mu     sync.Mutex
+	file   *os.File
+	size   int64
+	window time.Time
+	closed bool
+
+	millCh chan struct{}
+	wg     sync.WaitGroup
+}
+
+// NewFileLogWriter creates a new FileLogWriter writing to filename.
+// If the file exists, logs are appended to it.
+func NewFileLogWriter(filename string, opts ...fileOption) (*FileLogWriter, error) {
+	w := &FileLogWriter{
+		filename: filename,
+		compress: true,
+		now:      time.Now,
+		millCh:   make(chan struct{}, 1),
+	}
+	for _, fn := range opts {
+		fn(w)
+	}
+
+	if err := w.open(); err != nil {
+		return nil, fmt.Errorf("new file log writer: %w", err)
+	}
+
+	w.wg.Add(1)
+	go w.runMill(w.millCh)
+
+	return w, nil
+}
+
+// Write writes a log. The file is rotated before writing if it exceeds the max size or the time window has passed.
+func (w *FileLogWriter) Write(p []byte) (n int, err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed || w.file == nil {
+		return 0, fmt.Errorf("file log writer write: %w", ErrClosed)
+	}
+
+	if w.shouldRotate(len(p)) {
+		if err := w.rotate(); err != nil {
+			return 0, fmt.Errorf("file log writer write: %w", err)
+		}
+	}
+
+	n, err = w.file.Write(p)
+	w.size += int64(n)
+	if err != nil {
+		return n, fmt.Errorf("file log writer write: %w", err)
+	}
+	return n, nil
+}
+
+// WriteBatch implements BatchWriter, since logs are appended to the file as they are.
+func (w *FileLogWriter) WriteBatch(logs []byte) error {
+	_, err := w.Write(logs)
+	return err
+}
+
+// Rotate rotates the file immediately.
+func (w *FileLogWriter) Rotate() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return fmt.Errorf("rotate file log writer: %w", ErrClosed)
+	}
+
+	if err := w.rotate(); err != nil {
+		return fmt.Errorf("rotate file log writer: %w", err)
+	}
+	return nil
+}
+
+// Reopen closes and reopens the file. It is for external rotation like logrotate,
+// and usually called on SIGHUP.
+//
+//	c := make(chan os.Signal, 1)
+//	signal.Notify(c, syscall.SIGHUP)
+//	go func() {
+//		for range c {
+//			w.Reopen()
+//		}
+//	}()
+func (w *FileLogWriter) Reopen() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return fmt.Errorf("reopen file log writer: %w", ErrClosed)
+	}
+
+	if err := w.closeFile(); err != nil {
+		return fmt.Errorf("reopen file log writer: %w", err)
+	}
+	if err := w.open(); err != nil {
+		return fmt.Errorf("reopen file log writer: %w", err)
+	}
+	return nil
+}
+
+// Close closes the file, and waits for the background compression and cleanup to finish.
+// Write, Rotate and Reopen return ErrClosed after closed.
+func (w *FileLogWriter) Close() error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return nil
+	}
+	w.closed = true
+	err := w.closeFile()
+	close(w.millCh)
+	w.mu.Unlock()
+
+	w.wg.Wait()
+
+	if err != nil {
+		return fmt.Errorf("close file log writer: %w", err)
+	}
+	return nil
+}
+
+// open opens the file to append logs. w.mu must be held.
+func (w *FileLogWriter) open() error {
+	if err := os.MkdirAll(filepath.Dir(w.filename), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(w.filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	w.file = f
+	w.size = fi.Size()
+	w.window = w.windowOf(w.now())
+	return nil
+}
+
+// closeFile closes the file. w.mu must be held.
+func (w *FileLogWriter) closeFile() error {
+	if w.file == nil {
+		return nil
+	}
+	err := w.file.Close()
+	w.file = nil
+	return err
+}
+
+// windowOf returns the start of the time window t belongs to.
+func (w *FileLogWriter) windowOf(t time.Time) time.Time {
+	if w.interval <= 0 {
+		return time.Time{}
+	}
+	return t.UTC().Truncate(w.interval)
+}
+
+// shouldRotate reports whether the file should be rotated before writing n bytes. w.mu must be held.
+func (w *FileLogWriter) shouldRotate(n int) bool {
+	if w.maxSize > 0 && w.size > 0 && w.size+int64(n) > w.maxSize {
+		return true
+	}
+	return w.interval > 0 && !w.windowOf(w.now()).Equal(w.window)
+}
+
+// rotate renames the file with the rotation time, and opens a new file. w.mu must be held.
+func (w *FileLogWriter) rotate() error {
+	if err := w.closeFile(); err != nil {
+		return err
+	}
+	if err := os.Rename(w.filename, w.uniqueBackupName(w.now())); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := w.open(); err != nil {
+		return err
+	}
+
+	select {
+	case w.millCh <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// backupName returns the name of the file rotated at t.
+func (w *FileLogWriter) backupName(t time.Time) string {
+	dir, prefix, ext := w.nameParts()
+	return filepath.Join(dir, prefix+t.UTC().Format(backupTimeFormat)+ext)
+}
+
+// uniqueBackupName returns the name of the file rotated at t not to overwrite existing rotated files.
+func (w *FileLogWriter) uniqueBackupName(t time.Time) string {
+	for {
+		name := w.backupName(t)
+		if _, err := os.Stat(name); os.IsNotExist(err) {
+			if _, err := os.Stat(name + compressSuffix); os.IsNotExist(err) && !w.encodedExists(name) {
+				return name
+			}
+		}
+		t = t.Add(time.Millisecond)
+	}
+}
+
+// encodedExists reports whether the file encoded from the rotated file name exists.
+func (w *FileLogWriter) encodedExists(name string) bool {
+	if w.encoder == nil {
+		return false
+	}
+	_, err := os.Stat(strings.TrimSuffix(name, filepath.Ext(w.filename)) + w.encoder.Ext())
+	return err == nil
+}
+
+// nameParts returns the directory, the prefix and the extension of rotated files.
+func (w *FileLogWriter) nameParts() (dir, prefix, ext string) {
+	dir = filepath.Dir(w.filename)
+	base := filepath.Base(w.filename)
+	ext = filepath.Ext(base)
+	return dir, strings.TrimSuffix(base, ext) + "-", ext
+}
+
+// runMill compresses and cleans up rotated files whenever the file is rotated.
+func (w *FileLogWriter) runMill(millCh <-chan struct{}) {
+	defer w.wg.Done()
+
+	w.mill()
+	for range millCh {
+		w.mill()
+	}
+}
+
+// backup is a rotated file.
+type backup struct {
+	path string
+	t    time.Time
+	// raw reports whether the file is neither compressed nor encoded yet.
+	raw bool
+}
+
+// mill compresses rotated files, and removes the files exceeding the max backups or the max age.
+func (w *FileLogWriter) mill() {
+	backups, err := w.backups()
+	if err != nil {
+		return
+	}
+
+	var remove []backup
+	if w.maxBackups > 0 && len(backups) > w.maxBackups {
+		remove = append(remove, backups[w.maxBackups:]...)
+		backups = backups[:w.maxBackups]
+	}
+	if w.maxAge > 0 {
+		cutoff := w.now().Add(-w.maxAge)
+		var kept []backup
+		for _, b := range backups {
+			if b.t.Before(cutoff) {
+				remove = append(remove, b)
+			} else {
+				kept = append(kept, b)
+			}
+		}
+		backups = kept
+	}
+
+	for _, b := range remove {
+		_ = os.Remove(b.path)
+	}
+	for _, b := range backups {
+		if !b.raw {
+			continue
+		}
+		if w.encoder != nil {
+			_ = encodeFile(w.encoder, b.path, strings.TrimSuffix(b.path, filepath.Ext(w.filename))+w.encoder.Ext())
+		} else if w.compress {
+			_ = compressFile(b.path, b.path+compressSuffix)
+		}
+	}
+}
+
+// backups returns the rotated files sorted by newest first.
+func (w *FileLogWriter) backups() ([]backup, error) {
+	dir, prefix, ext := w.nameParts()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var backups []backup
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		ts := strings.TrimPrefix(name, prefix)
+		if len(ts) < len(backupTimeFormat) {
+			continue
+		}
+		t, err := time.Parse(backupTimeFormat, ts[:len(backupTimeFormat)])
+		if err != nil {
+			continue
+		}
+		backups = append(backups, backup{path: filepath.Join(dir, name), t: t, raw: ts[len(backupTimeFormat):] == ext})
+	}
+
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].t.After(backups[j].t)
+	})
+	return backups, nil
+}
+
+// encodeFile encodes src into dst by enc, and removes src.
+// src is streamed into enc if it is StreamEncoder, otherwise loaded into memory.
+func encodeFile(enc Encoder, src, dst string) error {
+	r, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	f, err := os.OpenFile(dst, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if se, ok := enc.(StreamEncoder); ok {
+		err = se.EncodeStream(f, r)
+	} else {
+		var b []byte
+		if b, err = io.ReadAll(r); err == nil {
+			err = enc.Encode(f, b)
+		}
+	}
+	if err != nil {
+		f.Close()
+		os.Remove(dst)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(dst)
+		return err
+	}
+
+	return os.Remove(src)
+}
+
+// compressFile compresses src into dst with gzip, and removes src.
+func compressFile(src, dst string) error {
+	f, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	gf, err := os.OpenFile(dst, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+
+	gz := gzip.NewWriter(gf)
+	if _, err := io.Copy(gz, f); err != nil {
+		gf.Close()
+		os.Remove(dst)
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		gf.Close()
+		os.Remove(dst)
+		return err
+	}
+	if err := gf.Close(); err != nil {
+		os.Remove(dst)
+		return err
+	}
+
+	return os.Remove(src)
+}
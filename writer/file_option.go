@@ -0,0 +1,49 @@
+package writer
+
+import "time"
+
+type fileOption func(w *FileLogWriter)
+
+// FileMaxSize specifies the maximum bytes of the file before it is rotated. Zero means no limit.
+func FileMaxSize(n int64) fileOption {
+	return func(w *FileLogWriter) {
+		w.maxSize = n
+	}
+}
+
+// FileRotateInterval specifies the time window of the file. e.g. time.Hour rotates the file at the beginning of every hour in UTC.
+// Zero means no rotation by time.
+func FileRotateInterval(d time.Duration) fileOption {
+	return func(w *FileLogWriter) {
+		w.interval = d
+	}
+}
+
+// FileMaxAge specifies the maximum age of rotated files to retain. Zero means no limit.
+func FileMaxAge(d time.Duration) fileOption {
+	return func(w *FileLogWriter) {
+		w.maxAge = d
+	}
+}
+
+// FileMaxBackups specifies the maximum number of rotated files to retain. Zero means no limit.
+func FileMaxBackups(n int) fileOption {
+	return func(w *FileLogWriter) {
+		w.maxBackups = n
+	}
+}
+
+// FileCompress specifies whether rotated files should be compressed with gzip. The default is true.
+func FileCompress(compress bool) fileOption {
+	return func(w *FileLogWriter) {
+		w.compress = compress
+	}
+}
+
+// FileEncoder specifies the encoder of rotated files, e.g. ParquetEncoder.
+// Rotated files are encoded instead of compressed, and renamed with the extension of the encoder like "access-2021-12-09T02-39-46.026.parquet".
+func FileEncoder(enc Encoder) fileOption {
+	return func(w *FileLogWriter) {
+		w.encoder = enc
+	}
+}
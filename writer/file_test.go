@@ -0,0 +1,171 @@
+package writer
+
+import (
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+)
+
+func listDir(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestFileLogWriter_rotateBySize(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2021, 12, 9, 2, 39, 46, 0, time.UTC)
+	w, err := NewFileLogWriter(filepath.Join(dir, "access.log"), FileMaxSize(10), FileMaxBackups(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.now = func() time.Time { return now }
+
+	for i := 0; i < 4; i++ {
+		if _, err := w.Write([]byte("0123456\n")); err != nil {
+			t.Fatal(err)
+		}
+		now = now.Add(time.Second)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"access-2021-12-09T02-39-48.000.log.gz",
+		"access-2021-12-09T02-39-49.000.log.gz",
+		"access.log",
+	}
+	got := listDir(t, dir)
+	if len(got) != len(want) {
+		t.Fatalf("files = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("files = %v, want %v", got, want)
+		}
+	}
+
+	f, err := os.Open(filepath.Join(dir, want[0]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := io.ReadAll(gz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "0123456\n" {
+		t.Errorf("rotated file = %q, want %q", b, "0123456\n")
+	}
+}
+
+func TestFileLogWriter_rotateByTime(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2021, 12, 9, 2, 59, 59, 0, time.UTC)
+	w, err := NewFileLogWriter(filepath.Join(dir, "access.log"), FileRotateInterval(time.Hour), FileCompress(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.now = func() time.Time { return now }
+	w.window = w.windowOf(now)
+
+	if _, err := w.Write([]byte("a\n")); err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(time.Second)
+	if _, err := w.Write([]byte("b\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := os.ReadFile(filepath.Join(dir, "access-2021-12-09T03-00-00.000.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "a\n" {
+		t.Errorf("rotated file = %q, want %q", b, "a\n")
+	}
+}
+
+func TestFileLogWriter_Reopen(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "access.log")
+	w, err := NewFileLogWriter(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	if _, err := w.Write([]byte("a\n")); err != nil {
+		t.Fatal(err)
+	}
+	// rotated by logrotate
+	if err := os.Rename(name, name+".1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Reopen(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte("b\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "b\n" {
+		t.Errorf("reopened file = %q, want %q", b, "b\n")
+	}
+}
+
+func TestFileLogWriter_closed(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "access.log")
+	w, err := NewFileLogWriter(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(name); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := w.Write([]byte("a\n")); !errors.Is(err, ErrClosed) {
+		t.Errorf("Write() = %v, want ErrClosed", err)
+	}
+	if err := w.Rotate(); !errors.Is(err, ErrClosed) {
+		t.Errorf("Rotate() = %v, want ErrClosed", err)
+	}
+	if err := w.Reopen(); !errors.Is(err, ErrClosed) {
+		t.Errorf("Reopen() = %v, want ErrClosed", err)
+	}
+	if got := listDir(t, dir); len(got) != 0 {
+		t.Errorf("files = %v, want none recreated after closed", got)
+	}
+	if err := w.Close(); err != nil {
+		t.Errorf("Close() = %v, want nil on the second call", err)
+	}
+}
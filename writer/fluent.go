@@ -1,8 +1,10 @@
 package writer
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
 	"time"
 
 	"github.com/fluent/fluent-logger-golang/fluent"
@@ -54,15 +56,20 @@ func (f *FluentLogWriter) Close() error {
 	return nil
 }
 
-// Write writes a log.
+// Write writes a log. p may contain multiple newline delimited logs, e.g. a batch written by AsyncLogWriter.
 func (f *FluentLogWriter) Write(p []byte) (n int, err error) {
-	var m map[string]interface{}
-	if err := json.Unmarshal(p, &m); err != nil {
-		return 0, fmt.Errorf("fluent logger write: %w", err)
-	}
+	d := json.NewDecoder(bytes.NewReader(p))
+	for {
+		var m map[string]interface{}
+		if err := d.Decode(&m); err == io.EOF {
+			break
+		} else if err != nil {
+			return 0, fmt.Errorf("fluent logger write: %w", err)
+		}
 
-	if err := f.forwarder.Post(f.tag, m); err != nil {
-		return 0, fmt.Errorf("fluent logger write: %w", err)
+		if err := f.forwarder.Post(f.tag, m); err != nil {
+			return 0, fmt.Errorf("fluent logger write: %w", err)
+		}
 	}
 
 	return len(p), nil
@@ -0,0 +1,222 @@
+package writer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/xitongsys/parquet-go-source/writerfile"
+	"github.com/xitongsys/parquet-go/parquet"
+	pqwriter "github.com/xitongsys/parquet-go/writer"
+
+	"github.com/daangn/accesslog"
+)
+
+const (
+	defaultParquetRowGroupSize = 128 << 20
+	defaultParquetExtraColumn  = "extra"
+)
+
+// ParquetEncoder is the Encoder that encodes logs into Parquet.
+// Columns are derived from accesslog.Schema, and the fields not in the schema like ones added by LogEntry.Add
+// are collapsed into a map column of strings, where values other than strings are kept in JSON.
+type ParquetEncoder struct {
+	columns      []accesslog.Column
+	names        map[string]string
+	extraColumn  string
+	rowGroupSize int64
+	compression  parquet.CompressionCodec
+
+	schema string
+}
+
+// NewParquetEncoder returns a new ParquetEncoder encoding logs with the columns of s.
+func NewParquetEncoder(s *accesslog.Schema, opts ...parquetOption) (*ParquetEncoder, error) {
+	enc := &ParquetEncoder{
+		columns:      s.Columns,
+		names:        make(map[string]string, len(s.Columns)),
+		extraColumn:  defaultParquetExtraColumn,
+		rowGroupSize: defaultParquetRowGroupSize,
+		compression:  parquet.CompressionCodec_SNAPPY,
+	}
+	for _, fn := range opts {
+		fn(enc)
+	}
+
+	fields := make([]string, 0, len(enc.columns)+1)
+	for _, c := range enc.columns {
+		id := c.Identifier()
+		if id == enc.extraColumn {
+			return nil, fmt.Errorf("new parquet encoder: column %s conflicts with the extra column", c.Name)
+		}
+		enc.names[c.Name] = id
+		fields = append(fields, parquetField(c))
+	}
+	fields = append(fields, fmt.Sprintf(`{"Tag":"name=%s, type=MAP, repetitiontype=OPTIONAL","Fields":[`+
+		`{"Tag":"name=key, type=BYTE_ARRAY, convertedtype=UTF8, repetitiontype=REQUIRED"},`+
+		`{"Tag":"name=value, type=BYTE_ARRAY, convertedtype=UTF8, repetitiontype=OPTIONAL"}]}`, enc.extraColumn))
+	enc.schema = `{"Tag":"name=accesslog, repetitiontype=REQUIRED","Fields":[` + strings.Join(fields, ",") + `]}`
+
+	return enc, nil
+}
+
+// parquetField returns the field of the JSON schema of Parquet for the column c.
+func parquetField(c accesslog.Column) string {
+	name := c.Identifier()
+	switch c.Type {
+	case accesslog.IntColumn:
+		return fmt.Sprintf(`{"Tag":"name=%s, type=INT64, repetitiontype=OPTIONAL"}`, name)
+	case accesslog.FloatColumn:
+		return fmt.Sprintf(`{"Tag":"name=%s, type=DOUBLE, repetitiontype=OPTIONAL"}`, name)
+	case accesslog.BoolColumn:
+		return fmt.Sprintf(`{"Tag":"name=%s, type=BOOLEAN, repetitiontype=OPTIONAL"}`, name)
+	case accesslog.StringsColumn:
+		return fmt.Sprintf(`{"Tag":"name=%s, type=LIST, repetitiontype=OPTIONAL","Fields":[`+
+			`{"Tag":"name=element, type=BYTE_ARRAY, convertedtype=UTF8, repetitiontype=REQUIRED"}]}`, name)
+	case accesslog.StructColumn:
+		fields := make([]string, 0, len(c.Fields))
+		for _, f := range c.Fields {
+			fields = append(fields, parquetField(f))
+		}
+		return fmt.Sprintf(`{"Tag":"name=%s, repetitiontype=OPTIONAL","Fields":[%s]}`, name, strings.Join(fields, ","))
+	default:
+		return fmt.Sprintf(`{"Tag":"name=%s, type=BYTE_ARRAY, convertedtype=UTF8, repetitiontype=OPTIONAL"}`, name)
+	}
+}
+
+// Encode implements Encoder. Lines that aren't JSON objects are skipped.
+func (enc *ParquetEncoder) Encode(w io.Writer, logs []byte) error {
+	return enc.EncodeStream(w, bytes.NewReader(logs))
+}
+
+// EncodeStream implements StreamEncoder. Rows are buffered up to the row group size.
+func (enc *ParquetEncoder) EncodeStream(w io.Writer, r io.Reader) error {
+	pw, err := pqwriter.NewJSONWriter(enc.schema, writerfile.NewWriterFile(w), 1)
+	if err != nil {
+		return fmt.Errorf("parquet encode: %w", err)
+	}
+	pw.RowGroupSize = enc.rowGroupSize
+	pw.CompressionType = enc.compression
+
+	br := bufio.NewReader(r)
+	for {
+		line, err := br.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return fmt.Errorf("parquet encode: %w", err)
+		}
+		if row, ok := enc.row(line); ok {
+			if err := pw.Write(row); err != nil {
+				return fmt.Errorf("parquet encode: %w", err)
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+	}
+	if err := pw.WriteStop(); err != nil {
+		return fmt.Errorf("parquet encode: %w", err)
+	}
+	return nil
+}
+
+// row converts a log to the row of the schema in JSON.
+func (enc *ParquetEncoder) row(line []byte) (string, bool) {
+	var fields map[string]json.RawMessage
+	d := json.NewDecoder(bytes.NewReader(line))
+	d.UseNumber()
+	if err := d.Decode(&fields); err != nil || fields == nil {
+		return "", false
+	}
+
+	row := make(map[string]interface{}, len(enc.columns)+1)
+	for _, c := range enc.columns {
+		v, ok := fields[c.Name]
+		if !ok {
+			continue
+		}
+		delete(fields, c.Name)
+		if v, ok := columnValue(c, v); ok {
+			row[enc.names[c.Name]] = v
+		}
+	}
+	if len(fields) != 0 {
+		extra := make(map[string]string, len(fields))
+		for k, v := range fields {
+			extra[k] = stringValue(v)
+		}
+		row[enc.extraColumn] = extra
+	}
+
+	b, err := json.Marshal(row)
+	if err != nil {
+		return "", false
+	}
+	return string(b), true
+}
+
+// columnValue converts v to the value of the column c. Values not convertible are omitted.
+func columnValue(c accesslog.Column, v json.RawMessage) (interface{}, bool) {
+	d := json.NewDecoder(bytes.NewReader(v))
+	d.UseNumber()
+	var x interface{}
+	if err := d.Decode(&x); err != nil || x == nil {
+		return nil, false
+	}
+
+	switch c.Type {
+	case accesslog.IntColumn:
+		n, ok := x.(json.Number)
+		if !ok {
+			return nil, false
+		}
+		i, err := n.Int64()
+		return i, err == nil
+	case accesslog.FloatColumn:
+		n, ok := x.(json.Number)
+		if !ok {
+			return nil, false
+		}
+		f, err := n.Float64()
+		return f, err == nil
+	case accesslog.BoolColumn:
+		b, ok := x.(bool)
+		return b, ok
+	case accesslog.StringsColumn:
+		var ss []string
+		err := json.Unmarshal(v, &ss)
+		return ss, err == nil
+	case accesslog.StructColumn:
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(v, &fields); err != nil {
+			return nil, false
+		}
+		m := make(map[string]interface{}, len(c.Fields))
+		for _, f := range c.Fields {
+			if fv, ok := fields[f.Name]; ok {
+				if fv, ok := columnValue(f, fv); ok {
+					m[f.Identifier()] = fv
+				}
+			}
+		}
+		return m, true
+	default:
+		return stringValue(v), true
+	}
+}
+
+// stringValue returns v if it is a JSON string, otherwise v in JSON as it is.
+func stringValue(v json.RawMessage) string {
+	var s string
+	if err := json.Unmarshal(v, &s); err == nil {
+		return s
+	}
+	return string(v)
+}
+
+// Ext implements Encoder.
+func (enc *ParquetEncoder) Ext() string {
+	return ".parquet"
+}
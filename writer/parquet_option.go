@@ -0,0 +1,33 @@
+package writer
+
+import "github.com/xitongsys/parquet-go/parquet"
+
+type parquetOption func(enc *ParquetEncoder)
+
+// ParquetRowGroupSize specifies the maximum bytes of a row group. The default is 128MiB.
+func ParquetRowGroupSize(n int64) parquetOption {
+	return func(enc *ParquetEncoder) {
+		enc.rowGroupSize = n
+	}
+}
+
+// ParquetCompression specifies the compression of columns. The default is snappy.
+func ParquetCompression(c Compression) parquetOption {
+	return func(enc *ParquetEncoder) {
+		switch c {
+		case CompressionGzip:
+			enc.compression = parquet.CompressionCodec_GZIP
+		case CompressionZstd:
+			enc.compression = parquet.CompressionCodec_ZSTD
+		default:
+			enc.compression = parquet.CompressionCodec_UNCOMPRESSED
+		}
+	}
+}
+
+// ParquetExtraColumn specifies the name of the map column for the fields not in the schema. The default is "extra".
+func ParquetExtraColumn(name string) parquetOption {
+	return func(enc *ParquetEncoder) {
+		enc.extraColumn = name
+	}
+}
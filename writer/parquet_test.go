@@ -0,0 +1,174 @@
+package writer
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xitongsys/parquet-go-source/buffer"
+	"github.com/xitongsys/parquet-go/reader"
+
+	"github.com/daangn/accesslog"
+)
+
+func readParquet(t *testing.T, b []byte) []map[string]interface{} {
+	t.Helper()
+	f, err := buffer.NewBufferFile(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pr, err := reader.NewParquetReader(f, nil, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pr.ReadStop()
+
+	rows, err := pr.ReadByNumber(int(pr.GetNumRows()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	j, err := json.Marshal(rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ms []map[string]interface{}
+	if err := json.Unmarshal(j, &ms); err != nil {
+		t.Fatal(err)
+	}
+	return ms
+}
+
+func TestParquetEncoder_Encode(t *testing.T) {
+	s := accesslog.HTTPSchema(accesslog.NewDefaultHTTPLogFormatter(accesslog.WithBytesIn(), accesslog.WithHeaders("x-request-id:rid")))
+	enc, err := NewParquetEncoder(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logs := `{"protocol":"http","path":"/ping","status":"200","elapsed(ms)":0.5,"bytes_in":12,"rid":"abc","user":"foo","data":{"a":1}}` + "\n" +
+		`not json` + "\n" +
+		`{"protocol":"http","path":"/pong","status":"404","bytes_in":"x"}` + "\n"
+	var buf bytes.Buffer
+	if err := enc.Encode(&buf, []byte(logs)); err != nil {
+		t.Fatal(err)
+	}
+
+	rows := readParquet(t, buf.Bytes())
+	if len(rows) != 2 {
+		t.Fatalf("rows = %d, want 2", len(rows))
+	}
+
+	got, _ := json.Marshal(rows[0])
+	for _, want := range []string{`"Path":"/ping"`, `"Status":"200"`, `"Elapsed_ms":0.5`, `"Bytes_in":12`, `"Rid":"abc"`, `"data"`, `"{\"a\":1}"`, `"foo"`} {
+		if !strings.Contains(string(got), want) {
+			t.Errorf("row = %s, want to contain %s", got, want)
+		}
+	}
+	if rows[1]["Bytes_in"] != nil {
+		t.Errorf("Bytes_in = %v, want nil", rows[1]["Bytes_in"])
+	}
+}
+
+func TestNewParquetEncoder_conflict(t *testing.T) {
+	s := &accesslog.Schema{Columns: []accesslog.Column{{Name: "extra"}}}
+	if _, err := NewParquetEncoder(s); err == nil {
+		t.Error("NewParquetEncoder() must fail if a column conflicts with the extra column")
+	}
+}
+
+func TestFileLogWriter_encoder(t *testing.T) {
+	dir := t.TempDir()
+	enc, err := NewParquetEncoder(accesslog.GRPCSchema(accesslog.NewDefaultGRPCLogFormatter()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2021, 12, 9, 2, 39, 46, 0, time.UTC)
+	w, err := NewFileLogWriter(filepath.Join(dir, "access.log"), FileEncoder(enc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.now = func() time.Time { return now }
+
+	if _, err := w.Write([]byte(`{"protocol":"grpc","method":"/a"}` + "\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"access-2021-12-09T02-39-46.000.parquet", "access.log"}
+	got := listDir(t, dir)
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("files = %v, want %v", got, want)
+	}
+	b, err := os.ReadFile(filepath.Join(dir, want[0]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rows := readParquet(t, b); len(rows) != 1 || rows[0]["Method"] != "/a" {
+		t.Errorf("rows = %v", rows)
+	}
+}
+
+func TestParquetEncoder_EncodeV2(t *testing.T) {
+	s := accesslog.GRPCSchema(accesslog.NewDefaultGRPCLogFormatter(
+		accesslog.WithSchemaVersion(accesslog.SchemaV2),
+		accesslog.WithMetadata("x-ids:ids"),
+	))
+	enc, err := NewParquetEncoder(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	logs := `{"protocol":"grpc","status":5,"status_name":"NotFound","elapsed_us":1200,"schema_version":2,"ids":["a","b"]}` + "\n"
+	if err := enc.Encode(&buf, []byte(logs)); err != nil {
+		t.Fatal(err)
+	}
+
+	rows := readParquet(t, buf.Bytes())
+	if len(rows) != 1 {
+		t.Fatalf("rows = %d, want 1", len(rows))
+	}
+	got, _ := json.Marshal(rows[0])
+	for _, want := range []string{`"Status":5`, `"Status_name":"NotFound"`, `"Elapsed_us":1200`, `"Schema_version":2`, `"Ids":["a","b"]`} {
+		if !strings.Contains(string(got), want) {
+			t.Errorf("row = %s, want to contain %s", got, want)
+		}
+	}
+}
+
+func TestParquetEncoder_EncodeNested(t *testing.T) {
+	s := accesslog.HTTPSchema(accesslog.NewDefaultHTTPLogFormatter(accesslog.WithFieldNaming(accesslog.GCPNaming())))
+	enc, err := NewParquetEncoder(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	logs := `{"protocol":"http","path":"/a","httpRequest":{"requestUrl":"http://example.com/a","status":"200","latency":"0.001500000s"}}` + "\n"
+	if err := enc.Encode(&buf, []byte(logs)); err != nil {
+		t.Fatal(err)
+	}
+
+	rows := readParquet(t, buf.Bytes())
+	if len(rows) != 1 {
+		t.Fatalf("rows = %d, want 1", len(rows))
+	}
+	got, _ := json.Marshal(rows[0])
+	for _, want := range []string{`"Requesturl":"http://example.com/a"`, `"Latency":"0.001500000s"`} {
+		if !strings.Contains(string(got), want) {
+			t.Errorf("row = %s, want to contain %s", got, want)
+		}
+	}
+	if rows[0]["Extra"] != nil {
+		t.Errorf("Extra = %v, want nil", rows[0]["Extra"])
+	}
+}
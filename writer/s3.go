@@ -0,0 +1,588 @@
+package writer
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultS3MaxSize        = 16 << 20
+	defaultS3MaxAge         = 5 * time.Minute
+	defaultS3RetryInterval  = 30 * time.Second
+	defaultS3PartitionField = "protocol"
+
+	s3CheckInterval = time.Second
+	spoolTempSuffix = ".tmp"
+	spoolKeySuffix  = ".key"
+	spoolFailedDir  = "failed"
+)
+
+// S3Uploader is the interface for uploading objects to S3.
+// It can be implemented with the uploader of AWS SDK, or a fake for testing.
+type S3Uploader interface {
+	Upload(ctx context.Context, key string, body io.Reader) error
+}
+
+// S3UploaderFunc is an adapter to allow the use of ordinary functions as S3Uploader.
+type S3UploaderFunc func(ctx context.Context, key string, body io.Reader) error
+
+// Upload calls f(ctx, key, body).
+func (f S3UploaderFunc) Upload(ctx context.Context, key string, body io.Reader) error {
+	return f(ctx, key, body)
+}
+
+// S3LogWriter is the log writer that implements io.Writer.
+// It buffers newline delimited JSON logs, and uploads them to S3 as objects encoded by the encoder
+// under Hive-style partitions which Athena can query, like "dt=2021-12-09/hour=02/protocol=http/".
+// Objects are spooled in a local directory before uploading, and failed uploads are retried
+// from the directory, even after restart. An object failing to be uploaded doesn't block the others.
+type S3LogWriter struct {
+	uploader       S3Uploader
+	spoolDir       string
+	prefix         string
+	encoder        Encoder
+	maxSize        int
+	maxAge         time.Duration
+	retryInterval  time.Duration
+	partitionField string
+	maxAttempts    int
+	onError        func(err error)
+	now            func() time.Time
+
+	mu      sync.Mutex
+	buffers map[string]*s3Buffer
+	closed  bool
+
+	uploadMu sync.Mutex
+	attempts map[string]int
+	uploadCh chan struct{}
+	ctx      context.Context
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+}
+
+// s3Buffer is the buffer of logs in a partition.
+type s3Buffer struct {
+	partition string
+	created   time.Time
+	buf       bytes.Buffer
+}
+
+// NewS3LogWriter creates a new S3LogWriter uploading objects by u, spooling them in spoolDir.
+// Objects left in spoolDir by the previous run are uploaded in the background.
+func NewS3LogWriter(u S3Uploader, spoolDir string, opts ...s3Option) (*S3LogWriter, error) {
+	w := &S3LogWriter{
+		uploader:       u,
+		spoolDir:       spoolDir,
+		maxSize:        defaultS3MaxSize,
+		maxAge:         defaultS3MaxAge,
+		retryInterval:  defaultS3RetryInterval,
+		partitionField: defaultS3PartitionField,
+		encoder:        NewJSONEncoder(CompressionGzip),
+		now:            time.Now,
+		buffers:        map[string]*s3Buffer{},
+		attempts:       map[string]int{},
+		uploadCh:       make(chan struct{}, 1),
+	}
+	for _, fn := range opts {
+		fn(w)
+	}
+
+	if err := os.MkdirAll(spoolDir, 0o755); err != nil {
+		return nil, fmt.Errorf("new s3 log writer: %w", err)
+	}
+	if err := cleanSpool(spoolDir); err != nil {
+		return nil, fmt.Errorf("new s3 log writer: %w", err)
+	}
+
+	w.ctx, w.cancel = context.WithCancel(context.Background())
+	w.wg.Add(2)
+	go w.run()
+	go w.uploadLoop()
+	w.signal()
+
+	return w, nil
+}
+
+// Write buffers logs. p may contain multiple newline delimited logs.
+// Logs are partitioned by the time written and the value of the partition field.
+// Buffers failed to be spooled are kept to be spooled again, and the error is reported to the error handler.
+func (w *S3LogWriter) Write(p []byte) (n int, err error) {
+	now := w.now().UTC()
+
+	var full []*s3Buffer
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return 0, fmt.Errorf("s3 log writer write: %w", ErrClosed)
+	}
+	for _, line := range bytes.Split(p, []byte{'\n'}) {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+
+		partition := w.partition(now, line)
+		b, ok := w.buffers[partition]
+		if !ok {
+			b = &s3Buffer{partition: partition, created: now}
+			w.buffers[partition] = b
+		}
+		b.buf.Write(line)
+		b.buf.WriteByte('\n')
+
+		if b.buf.Len() >= w.maxSize {
+			delete(w.buffers, partition)
+			full = append(full, b)
+		}
+	}
+	w.mu.Unlock()
+
+	if err := w.spool(full); err != nil {
+		w.handleError(fmt.Errorf("s3 log writer spool: %w", err))
+	}
+	return len(p), nil
+}
+
+// Flush spools all buffered logs, and uploads all spooled objects.
+func (w *S3LogWriter) Flush(ctx context.Context) error {
+	if err := w.spool(w.takeBuffers(func(*s3Buffer) bool { return true })); err != nil {
+		return fmt.Errorf("flush s3 log writer: %w", err)
+	}
+	if err := w.upload(ctx); err != nil {
+		return fmt.Errorf("flush s3 log writer: %w", err)
+	}
+	return nil
+}
+
+// Close flushes all buffered logs and stops the background goroutine.
+// Objects failed to be uploaded until ctx is done are left in the spool directory for the next run.
+func (w *S3LogWriter) Close(ctx context.Context) error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return nil
+	}
+	w.closed = true
+	w.mu.Unlock()
+
+	w.cancel()
+	w.wg.Wait()
+
+	if err := w.Flush(ctx); err != nil {
+		return fmt.Errorf("close s3 log writer: %w", err)
+	}
+	return nil
+}
+
+// partition returns the Hive-style partition of line written at t.
+func (w *S3LogWriter) partition(t time.Time, line []byte) string {
+	v := "unknown"
+	if s, ok := stringField(line, w.partitionField); ok && s != "" {
+		v = s
+	}
+	return fmt.Sprintf("dt=%s/hour=%s/%s=%s", t.Format("2006-01-02"), t.Format("15"), w.partitionField, escapePartition(v))
+}
+
+// stringField returns the string value of the top-level field of the JSON object line.
+// It scans line without decoding the whole object, and decodes only the value.
+func stringField(line []byte, field string) (string, bool) {
+	depth, isKey := 0, false
+	for i := 0; i < len(line); i++ {
+		switch c := line[i]; c {
+		case '{', '[':
+			depth++
+			isKey = depth == 1 && c == '{'
+		case '}', ']':
+			depth--
+		case ',':
+			isKey = depth == 1
+		case '"':
+			end := stringEnd(line, i)
+			if end == -1 {
+				return "", false
+			}
+			if !isKey || depth != 1 {
+				i = end
+				continue
+			}
+			isKey = false
+			if string(line[i+1:end]) != field {
+				i = end
+				continue
+			}
+
+			j := end + 1
+			for j < len(line) && (line[j] == ' ' || line[j] == ':' || line[j] == '\t') {
+				j++
+			}
+			if j == len(line) || line[j] != '"' {
+				return "", false
+			}
+			vend := stringEnd(line, j)
+			if vend == -1 {
+				return "", false
+			}
+			var s string
+			if err := json.Unmarshal(line[j:vend+1], &s); err != nil {
+				return "", false
+			}
+			return s, true
+		}
+	}
+	return "", false
+}
+
+// stringEnd returns the index of the quote closing the JSON string starting at i, or -1.
+func stringEnd(line []byte, i int) int {
+	for j := i + 1; j < len(line); j++ {
+		switch line[j] {
+		case '\\':
+			j++
+		case '"':
+			return j
+		}
+	}
+	return -1
+}
+
+// escapePartition escapes the value of a partition like Hive escapes path names, so that it is
+// a single segment of object keys. Since '/' is escaped, the value can't traverse the keys by "..".
+func escapePartition(v string) string {
+	const special = "\"#%'*/:=?\\{[]^"
+	var b strings.Builder
+	for i := 0; i < len(v); i++ {
+		c := v[i]
+		if c < 0x20 || c == 0x7f || strings.IndexByte(special, c) != -1 {
+			fmt.Fprintf(&b, "%%%02X", c)
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+// takeBuffers removes the buffers satisfying fn and returns them.
+func (w *S3LogWriter) takeBuffers(fn func(b *s3Buffer) bool) []*s3Buffer {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var bs []*s3Buffer
+	for k, b := range w.buffers {
+		if fn(b) {
+			delete(w.buffers, k)
+			bs = append(bs, b)
+		}
+	}
+	return bs
+}
+
+// spool encodes bs into objects in the spool directory, and wakes the uploader up.
+// Buffers failed to be spooled are put back to be spooled again.
+func (w *S3LogWriter) spool(bs []*s3Buffer) error {
+	if len(bs) == 0 {
+		return nil
+	}
+	var (
+		failed []*s3Buffer
+		errs   []error
+	)
+	for _, b := range bs {
+		if err := w.spoolBuffer(b); err != nil {
+			failed = append(failed, b)
+			errs = append(errs, err)
+		}
+	}
+	w.restore(failed)
+	if len(failed) < len(bs) {
+		w.signal()
+	}
+	return errors.Join(errs...)
+}
+
+// restore puts bs back, followed by logs buffered in the same partitions meanwhile.
+// Like the live buffers, a partition keeps logs up to S3MaxSize, so that failing to spool for long doesn't exhaust memory.
+// The oldest logs beyond it are dropped and reported to the error handler.
+func (w *S3LogWriter) restore(bs []*s3Buffer) {
+	if len(bs) == 0 {
+		return
+	}
+	dropped := 0
+	w.mu.Lock()
+	for _, b := range bs {
+		if cur, ok := w.buffers[b.partition]; ok {
+			b.buf.Write(cur.buf.Bytes())
+		}
+		dropped += b.trim(w.maxSize)
+		w.buffers[b.partition] = b
+	}
+	w.mu.Unlock()
+
+	if dropped != 0 {
+		w.handleError(fmt.Errorf("s3 log writer spool: dropped %d logs beyond the max size", dropped))
+	}
+}
+
+// trim drops the oldest logs of b until it fits in max bytes, keeping the newest log at least.
+// It returns the number of dropped logs.
+func (b *s3Buffer) trim(max int) int {
+	data := b.buf.Bytes()
+	off, n := 0, 0
+	for len(data)-off > max {
+		i := bytes.IndexByte(data[off:], '\n')
+		if i == -1 || off+i+1 == len(data) {
+			break
+		}
+		off += i + 1
+		n++
+	}
+	if off != 0 {
+		// the buffer is copied not to hold the dropped logs.
+		var buf bytes.Buffer
+		buf.Write(data[off:])
+		b.buf = buf
+	}
+	return n
+}
+
+// spoolBuffer writes the object of b into the spool directory.
+// The file is named with the hash of the object key not to exceed the limit of file names, and the key is
+// written into the sidecar file with spoolKeySuffix. The object is renamed after written to be atomic,
+// so that every object has its sidecar file. Sidecar files left without objects are removed by cleanSpool.
+func (w *S3LogWriter) spoolBuffer(b *s3Buffer) error {
+	key := w.objectKey(b)
+	sum := sha256.Sum256([]byte(key))
+	name := filepath.Join(w.spoolDir, b.created.Format("20060102T150405Z")+"-"+hex.EncodeToString(sum[:]))
+	if err := os.WriteFile(name+spoolKeySuffix, []byte(key), 0o644); err != nil {
+		return err
+	}
+
+	f, err := os.Create(name + spoolTempSuffix)
+	if err != nil {
+		os.Remove(name + spoolKeySuffix)
+		return err
+	}
+
+	if err := w.encoder.Encode(f, b.buf.Bytes()); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		os.Remove(name + spoolKeySuffix)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		os.Remove(name + spoolKeySuffix)
+		return err
+	}
+	if err := os.Rename(f.Name(), name); err != nil {
+		os.Remove(f.Name())
+		os.Remove(name + spoolKeySuffix)
+		return err
+	}
+	return nil
+}
+
+// cleanSpool removes the files left by spooling interrupted in the previous run,
+// which are temporary objects and sidecar files without objects.
+func cleanSpool(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, e := range entries {
+		name := filepath.Join(dir, e.Name())
+		switch {
+		case e.IsDir():
+			continue
+		case strings.HasSuffix(name, spoolTempSuffix):
+		case strings.HasSuffix(name, spoolKeySuffix):
+			if _, err := os.Stat(strings.TrimSuffix(name, spoolKeySuffix)); !errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+		default:
+			continue
+		}
+		if err := os.Remove(name); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// objectKey returns the key of the object for b.
+func (w *S3LogWriter) objectKey(b *s3Buffer) string {
+	var r [8]byte
+	_, _ = rand.Read(r[:])
+	return fmt.Sprintf("%s%s/%s-%s%s", w.prefix, b.partition, b.created.Format("20060102T150405Z"), hex.EncodeToString(r[:]), w.encoder.Ext())
+}
+
+// signal wakes the uploader up without blocking.
+func (w *S3LogWriter) signal() {
+	select {
+	case w.uploadCh <- struct{}{}:
+	default:
+	}
+}
+
+// run spools expired buffers, and wakes the uploader up to retry failed uploads until stopped.
+func (w *S3LogWriter) run() {
+	defer w.wg.Done()
+
+	t := time.NewTicker(s3CheckInterval)
+	defer t.Stop()
+
+	var lastRetry time.Time
+	for {
+		select {
+		case <-w.ctx.Done():
+			return
+		case <-t.C:
+			now := w.now().UTC()
+			expired := w.takeBuffers(func(b *s3Buffer) bool {
+				return now.Sub(b.created) >= w.maxAge
+			})
+			if err := w.spool(expired); err != nil {
+				w.handleError(fmt.Errorf("s3 log writer spool: %w", err))
+			}
+			if now.Sub(lastRetry) >= w.retryInterval {
+				lastRetry = now
+				w.signal()
+			}
+		}
+	}
+}
+
+// uploadLoop uploads spooled objects whenever woken up until stopped.
+// Uploads in progress are canceled when stopped.
+func (w *S3LogWriter) uploadLoop() {
+	defer w.wg.Done()
+
+	for {
+		select {
+		case <-w.ctx.Done():
+			return
+		case <-w.uploadCh:
+			if err := w.upload(w.ctx); err != nil {
+				w.handleError(fmt.Errorf("s3 log writer upload: %w", err))
+			}
+		}
+	}
+}
+
+// upload uploads spooled objects in the order of names, and removes them after uploaded.
+// Objects failed to be uploaded are skipped to retry later, and moved to the directory spoolFailedDir
+// after failed S3MaxAttempts times.
+func (w *S3LogWriter) upload(ctx context.Context) error {
+	w.uploadMu.Lock()
+	defer w.uploadMu.Unlock()
+
+	entries, err := os.ReadDir(w.spoolDir)
+	if err != nil {
+		return err
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name() < entries[j].Name()
+	})
+
+	var errs []error
+	for _, e := range entries {
+		if e.IsDir() || strings.HasSuffix(e.Name(), spoolTempSuffix) || strings.HasSuffix(e.Name(), spoolKeySuffix) {
+			continue
+		}
+		if ctx.Err() != nil {
+			errs = append(errs, ctx.Err())
+			break
+		}
+
+		name := filepath.Join(w.spoolDir, e.Name())
+		key, err := spooledKey(name)
+		if err != nil {
+			continue
+		}
+		if err := w.uploadFile(ctx, key, name); err != nil {
+			errs = append(errs, fmt.Errorf("upload %s: %w", key, err))
+			if ctx.Err() == nil {
+				w.fail(name)
+			}
+			continue
+		}
+		delete(w.attempts, name)
+		if err := removeSpooled(name); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// spooledKey returns the object key of the spooled file name, read from the sidecar file.
+func spooledKey(name string) (string, error) {
+	b, err := os.ReadFile(name + spoolKeySuffix)
+	return string(b), err
+}
+
+// removeSpooled removes the spooled file name and its sidecar file.
+func removeSpooled(name string) error {
+	if err := os.Remove(name); err != nil {
+		return err
+	}
+	if err := os.Remove(name + spoolKeySuffix); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// fail records the failed attempt to upload the spooled file name, and moves the file and its sidecar file
+// to the directory spoolFailedDir if it failed S3MaxAttempts times.
+func (w *S3LogWriter) fail(name string) {
+	w.attempts[name]++
+	if w.maxAttempts <= 0 || w.attempts[name] < w.maxAttempts {
+		return
+	}
+	delete(w.attempts, name)
+
+	dir := filepath.Join(w.spoolDir, spoolFailedDir)
+	err := os.MkdirAll(dir, 0o755)
+	if err == nil {
+		err = os.Rename(name, filepath.Join(dir, filepath.Base(name)))
+	}
+	if err == nil {
+		err = os.Rename(name+spoolKeySuffix, filepath.Join(dir, filepath.Base(name)+spoolKeySuffix))
+		if errors.Is(err, os.ErrNotExist) {
+			err = nil
+		}
+	}
+	if err != nil {
+		w.handleError(fmt.Errorf("s3 log writer quarantine: %w", err))
+	}
+}
+
+// uploadFile uploads the file name as key.
+func (w *S3LogWriter) uploadFile(ctx context.Context, key, name string) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return w.uploader.Upload(ctx, key, f)
+}
+
+// handleError calls the error handler if set.
+func (w *S3LogWriter) handleError(err error) {
+	if w.onError != nil {
+		w.onError(err)
+	}
+}
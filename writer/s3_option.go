@@ -0,0 +1,70 @@
+package writer
+
+import "time"
+
+type s3Option func(w *S3LogWriter)
+
+// S3Prefix specifies the prefix of object keys, e.g. "accesslog/".
+func S3Prefix(prefix string) s3Option {
+	return func(w *S3LogWriter) {
+		w.prefix = prefix
+	}
+}
+
+// S3Compression specifies the compression of JSON lines objects. The default is CompressionGzip.
+func S3Compression(c Compression) s3Option {
+	return func(w *S3LogWriter) {
+		w.encoder = NewJSONEncoder(c)
+	}
+}
+
+// S3Encoder specifies the encoder of objects, e.g. ParquetEncoder. The default is JSON lines compressed with gzip.
+func S3Encoder(enc Encoder) s3Option {
+	return func(w *S3LogWriter) {
+		w.encoder = enc
+	}
+}
+
+// S3MaxSize specifies the maximum uncompressed bytes of a partition buffered before it is uploaded. The default is 16MiB.
+// Logs failing to be spooled are also kept up to n bytes for each partition.
+func S3MaxSize(n int) s3Option {
+	return func(w *S3LogWriter) {
+		w.maxSize = n
+	}
+}
+
+// S3MaxAge specifies the maximum age of a partition buffered before it is uploaded. The default is 5 minutes.
+func S3MaxAge(d time.Duration) s3Option {
+	return func(w *S3LogWriter) {
+		w.maxAge = d
+	}
+}
+
+// S3RetryInterval specifies the interval of retrying failed uploads. The default is 30 seconds.
+func S3RetryInterval(d time.Duration) s3Option {
+	return func(w *S3LogWriter) {
+		w.retryInterval = d
+	}
+}
+
+// S3PartitionField specifies the field of logs to partition objects by. The default is "protocol".
+func S3PartitionField(field string) s3Option {
+	return func(w *S3LogWriter) {
+		w.partitionField = field
+	}
+}
+
+// S3ErrorHandler specifies the handler of errors occurred in the background.
+func S3ErrorHandler(fn func(err error)) s3Option {
+	return func(w *S3LogWriter) {
+		w.onError = fn
+	}
+}
+
+// S3MaxAttempts specifies the number of failed attempts to upload an object before it is moved to
+// the "failed" directory in the spool directory, not to retry it forever. The default is 0, retrying forever.
+func S3MaxAttempts(n int) s3Option {
+	return func(w *S3LogWriter) {
+		w.maxAttempts = n
+	}
+}
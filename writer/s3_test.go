@@ -0,0 +1,369 @@
+package writer
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+type fakeUploader struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+	err     error
+}
+
+func (u *fakeUploader) Upload(ctx context.Context, key string, body io.Reader) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if u.err != nil {
+		return u.err
+	}
+	b, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+	if u.objects == nil {
+		u.objects = map[string][]byte{}
+	}
+	u.objects[key] = b
+	return nil
+}
+
+func (u *fakeUploader) setErr(err error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.err = err
+}
+
+func (u *fakeUploader) snapshot() map[string][]byte {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	m := map[string][]byte{}
+	for k, v := range u.objects {
+		m[k] = v
+	}
+	return m
+}
+
+func decompress(t *testing.T, c Compression, b []byte) string {
+	t.Helper()
+	var r io.Reader
+	switch c {
+	case CompressionGzip:
+		gz, err := gzip.NewReader(bytes.NewReader(b))
+		if err != nil {
+			t.Fatal(err)
+		}
+		r = gz
+	case CompressionZstd:
+		zr, err := zstd.NewReader(bytes.NewReader(b))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer zr.Close()
+		r = zr
+	default:
+		r = bytes.NewReader(b)
+	}
+	out, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(out)
+}
+
+func TestS3LogWriter_partition(t *testing.T) {
+	tests := []struct {
+		name        string
+		compression Compression
+		ext         string
+	}{
+		{name: "gzip", compression: CompressionGzip, ext: ".json.gz"},
+		{name: "zstd", compression: CompressionZstd, ext: ".json.zst"},
+		{name: "none", compression: CompressionNone, ext: ".json"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := &fakeUploader{}
+			now := func(w *S3LogWriter) {
+				w.now = func() time.Time { return time.Date(2021, 12, 9, 2, 39, 46, 0, time.UTC) }
+			}
+			w, err := NewS3LogWriter(u, t.TempDir(), S3Prefix("logs/"), S3Compression(tt.compression), now)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			logs := `{"protocol":"http","path":"/a"}` + "\n" + `{"protocol":"grpc","method":"/b"}` + "\n"
+			if _, err := w.Write([]byte(logs)); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := w.Write([]byte(`{"protocol":"http","path":"/c"}`)); err != nil {
+				t.Fatal(err)
+			}
+			if err := w.Close(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+
+			want := map[string]string{
+				"logs/dt=2021-12-09/hour=02/protocol=http/": `{"protocol":"http","path":"/a"}` + "\n" + `{"protocol":"http","path":"/c"}` + "\n",
+				"logs/dt=2021-12-09/hour=02/protocol=grpc/": `{"protocol":"grpc","method":"/b"}` + "\n",
+			}
+			objects := u.snapshot()
+			if len(objects) != len(want) {
+				t.Fatalf("objects = %d, want %d", len(objects), len(want))
+			}
+			for k, v := range objects {
+				i := strings.LastIndex(k, "/")
+				prefix, name := k[:i+1], k[i+1:]
+				if !strings.HasPrefix(name, "20211209T023946Z-") || !strings.HasSuffix(name, tt.ext) {
+					t.Errorf("key = %s", k)
+				}
+				if got := decompress(t, tt.compression, v); got != want[prefix] {
+					t.Errorf("object %s = %q, want %q", k, got, want[prefix])
+				}
+			}
+		})
+	}
+}
+
+func TestS3LogWriter_maxSize(t *testing.T) {
+	u := &fakeUploader{}
+	w, err := NewS3LogWriter(u, t.TempDir(), S3MaxSize(20), S3Compression(CompressionNone))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close(context.Background())
+
+	if _, err := w.Write([]byte(`{"protocol":"http"}`)); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.upload(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got := len(u.snapshot()); got != 1 {
+		t.Fatalf("objects = %d, want 1", got)
+	}
+}
+
+func TestS3LogWriter_retry(t *testing.T) {
+	dir := t.TempDir()
+	u := &fakeUploader{err: errors.New("unavailable")}
+
+	w, err := NewS3LogWriter(u, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte(`{"protocol":"http"}`)); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(context.Background()); err == nil {
+		t.Fatal("Close() must fail while the uploader is unavailable")
+	}
+	if got := listDir(t, dir); len(got) != 2 || got[1] != got[0]+spoolKeySuffix {
+		t.Fatalf("spooled = %v, want 1 object and its key", got)
+	}
+
+	// The spooled object is uploaded by the next run.
+	u.setErr(nil)
+	w, err = NewS3LogWriter(u, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got := len(u.snapshot()); got != 1 {
+		t.Fatalf("objects = %d, want 1", got)
+	}
+	if got := listDir(t, dir); len(got) != 0 {
+		t.Fatalf("spooled = %v, want none", got)
+	}
+}
+
+func TestS3LogWriter_spoolFailure(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "spool")
+	u := &fakeUploader{}
+	var errs []error
+	w, err := NewS3LogWriter(u, dir, S3MaxSize(10), S3Compression(CompressionNone), S3ErrorHandler(func(err error) {
+		errs = append(errs, err)
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	// The full buffer is kept while the spool directory is missing.
+	if n, err := w.Write([]byte(`{"protocol":"http","path":"/a"}`)); err != nil || n == 0 {
+		t.Fatalf("Write() = %d, %v", n, err)
+	}
+	if len(errs) != 1 {
+		t.Fatalf("errors = %v, want 1", errs)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte(`{"protocol":"http","path":"/b"}`)); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `{"protocol":"http","path":"/a"}` + "\n" + `{"protocol":"http","path":"/b"}` + "\n"
+	objects := u.snapshot()
+	if len(objects) != 1 {
+		t.Fatalf("objects = %d, want 1", len(objects))
+	}
+	for k, v := range objects {
+		if string(v) != want {
+			t.Errorf("object %s = %q, want %q", k, v, want)
+		}
+	}
+}
+
+func TestS3LogWriter_spoolFailureBounded(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "spool")
+	u := &fakeUploader{}
+	var errs []error
+	w, err := NewS3LogWriter(u, dir, S3MaxSize(40), S3Compression(CompressionNone), S3ErrorHandler(func(err error) {
+		errs = append(errs, err)
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	// The buffer kept while the spool directory is missing is bounded by the max size, dropping the oldest logs.
+	for _, l := range []string{`{"protocol":"http","n":1}`, `{"protocol":"http","n":2}`, `{"protocol":"http","n":3}`} {
+		if _, err := w.Write([]byte(l)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(errs) != 4 || !strings.Contains(errs[0].Error(), "dropped 1 logs") {
+		t.Errorf("errors = %v, want errors of spooling and dropping", errs)
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	for k, v := range u.snapshot() {
+		if want := `{"protocol":"http","n":3}` + "\n"; string(v) != want {
+			t.Errorf("object %s = %q, want %q", k, v, want)
+		}
+	}
+}
+
+func Test_cleanSpool(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a", "a.key", "b.key", "c.tmp"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := cleanSpool(dir); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := listDir(t, dir), []string{"a", "a.key"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("files = %v, want %v", got, want)
+	}
+}
+
+func TestS3LogWriter_failedObject(t *testing.T) {
+	dir := t.TempDir()
+	u := &fakeUploader{}
+	failing := S3UploaderFunc(func(ctx context.Context, key string, body io.Reader) error {
+		if strings.Contains(key, "protocol=poison/") {
+			return errors.New("access denied")
+		}
+		return u.Upload(ctx, key, body)
+	})
+
+	w, err := NewS3LogWriter(failing, dir, S3MaxAttempts(2), S3Prefix(strings.Repeat("long/", 100)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close(context.Background())
+
+	if _, err := w.Write([]byte(`{"protocol":"poison"}` + "\n" + `{"protocol":"http"}`)); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Flush(context.Background()); err == nil {
+		t.Fatal("Flush() must fail with the failed object")
+	}
+	// The failed object doesn't block the others.
+	if got := len(u.snapshot()); got != 1 {
+		t.Fatalf("objects = %d, want 1", got)
+	}
+
+	// The failed object is moved after failed twice, which may include an attempt in the background.
+	_ = w.Flush(context.Background())
+	if got := listDir(t, dir); len(got) != 1 || got[0] != spoolFailedDir {
+		t.Fatalf("spooled = %v, want only %s", got, spoolFailedDir)
+	}
+	if got := listDir(t, filepath.Join(dir, spoolFailedDir)); len(got) != 2 {
+		t.Fatalf("failed = %v, want 1 object and its key", got)
+	}
+	if err := w.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_stringField(t *testing.T) {
+	tests := []struct {
+		line string
+		want string
+		ok   bool
+	}{
+		{line: `{"protocol":"http"}`, want: "http", ok: true},
+		{line: `{"a":1, "protocol" : "grpc"}`, want: "grpc", ok: true},
+		{line: `{"req":{"protocol":"nested"},"protocol":"http"}`, want: "http", ok: true},
+		{line: `{"msg":"\"protocol\":\"x\"","protocol":"h\u0074tp"}`, want: "http", ok: true},
+		{line: `{"tags":["protocol","x"]}`},
+		{line: `{"protocol":1}`},
+		{line: `{"protocol":"unterminated`},
+		{line: `not json`},
+	}
+	for _, tt := range tests {
+		got, ok := stringField([]byte(tt.line), "protocol")
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("stringField(%s) = %q, %v, want %q, %v", tt.line, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func Test_escapePartition(t *testing.T) {
+	tests := []struct {
+		v    string
+		want string
+	}{
+		{v: "http", want: "http"},
+		{v: "../../etc", want: "..%2F..%2Fetc"},
+		{v: "a=b?c", want: "a%3Db%3Fc"},
+		{v: "\n", want: "%0A"},
+	}
+	for _, tt := range tests {
+		if got := escapePartition(tt.v); got != tt.want {
+			t.Errorf("escapePartition(%q) = %q, want %q", tt.v, got, tt.want)
+		}
+	}
+}
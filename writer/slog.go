@@ -0,0 +1,46 @@
+package writer
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+)
+
+// SlogLogWriter is the writer logging JSON logs of formatters to slog.Logger,
+// so that access logs are written by the backend a service configured.
+// Logs are embedded as raw JSON in the attribute "access_log" without being decoded.
+// Lines that aren't JSON objects are logged as messages.
+type SlogLogWriter struct {
+	l     *slog.Logger
+	level slog.Level
+	msg   string
+	key   string
+}
+
+// NewSlogLogWriter returns a new SlogLogWriter.
+func NewSlogLogWriter(l *slog.Logger, opts ...slogOption) *SlogLogWriter {
+	w := &SlogLogWriter{
+		l:     l,
+		level: slog.LevelInfo,
+		msg:   defaultBackendMessage,
+		key:   defaultBackendKey,
+	}
+	for _, fn := range opts {
+		fn(w)
+	}
+	return w
+}
+
+// Write implements io.Writer.
+func (w *SlogLogWriter) Write(p []byte) (int, error) {
+	ctx := context.Background()
+	eachLog(p, func(line []byte, isJSON bool) {
+		if !isJSON {
+			w.l.LogAttrs(ctx, w.level, string(line))
+			return
+		}
+		// copied, since handlers may keep attributes after Write returns.
+		w.l.LogAttrs(ctx, w.level, w.msg, slog.Any(w.key, json.RawMessage(append([]byte(nil), line...))))
+	})
+	return len(p), nil
+}
@@ -0,0 +1,26 @@
+package writer
+
+import "log/slog"
+
+type slogOption func(w *SlogLogWriter)
+
+// SlogLevel specifies the level of logs. The default is slog.LevelInfo.
+func SlogLevel(l slog.Level) slogOption {
+	return func(w *SlogLogWriter) {
+		w.level = l
+	}
+}
+
+// SlogMessage specifies the message of logs. The default is "access log".
+func SlogMessage(msg string) slogOption {
+	return func(w *SlogLogWriter) {
+		w.msg = msg
+	}
+}
+
+// SlogKey specifies the key of the field the logs are embedded in. The default is "access_log".
+func SlogKey(key string) slogOption {
+	return func(w *SlogLogWriter) {
+		w.key = key
+	}
+}
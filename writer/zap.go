@@ -0,0 +1,50 @@
+package writer
+
+import (
+	"encoding/json"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// ZapLogWriter is the writer logging JSON logs of formatters to zap.Logger,
+// so that access logs are written by the backend a service configured.
+// Logs are embedded as raw JSON in the field "access_log" without being decoded.
+// Lines that aren't JSON objects are logged as messages.
+type ZapLogWriter struct {
+	l     *zap.Logger
+	level zapcore.Level
+	msg   string
+	key   string
+}
+
+// NewZapLogWriter returns a new ZapLogWriter.
+func NewZapLogWriter(l *zap.Logger, opts ...zapOption) *ZapLogWriter {
+	w := &ZapLogWriter{
+		l:     l,
+		level: zapcore.InfoLevel,
+		msg:   defaultBackendMessage,
+		key:   defaultBackendKey,
+	}
+	for _, fn := range opts {
+		fn(w)
+	}
+	return w
+}
+
+// Write implements io.Writer.
+func (w *ZapLogWriter) Write(p []byte) (int, error) {
+	eachLog(p, func(line []byte, isJSON bool) {
+		if !isJSON {
+			if ce := w.l.Check(w.level, string(line)); ce != nil {
+				ce.Write()
+			}
+			return
+		}
+		if ce := w.l.Check(w.level, w.msg); ce != nil {
+			// copied, since cores may keep fields after Write returns.
+			ce.Write(zap.Reflect(w.key, json.RawMessage(append([]byte(nil), line...))))
+		}
+	})
+	return len(p), nil
+}
@@ -0,0 +1,26 @@
+package writer
+
+import "go.uber.org/zap/zapcore"
+
+type zapOption func(w *ZapLogWriter)
+
+// ZapLevel specifies the level of logs. The default is zapcore.InfoLevel.
+func ZapLevel(l zapcore.Level) zapOption {
+	return func(w *ZapLogWriter) {
+		w.level = l
+	}
+}
+
+// ZapMessage specifies the message of logs. The default is "access log".
+func ZapMessage(msg string) zapOption {
+	return func(w *ZapLogWriter) {
+		w.msg = msg
+	}
+}
+
+// ZapKey specifies the key of the field the logs are embedded in. The default is "access_log".
+func ZapKey(key string) zapOption {
+	return func(w *ZapLogWriter) {
+		w.key = key
+	}
+}
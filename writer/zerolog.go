@@ -0,0 +1,42 @@
+package writer
+
+import (
+	"github.com/rs/zerolog"
+)
+
+// ZerologLogWriter is the writer logging JSON logs of formatters to zerolog.Logger configured by a service,
+// with its context fields, hooks and level, instead of writing them as they are.
+// Logs are embedded as raw JSON in the field "access_log" without being decoded.
+// Lines that aren't JSON objects are logged as messages.
+type ZerologLogWriter struct {
+	l     zerolog.Logger
+	level zerolog.Level
+	msg   string
+	key   string
+}
+
+// NewZerologLogWriter returns a new ZerologLogWriter.
+func NewZerologLogWriter(l zerolog.Logger, opts ...zerologOption) *ZerologLogWriter {
+	w := &ZerologLogWriter{
+		l:     l,
+		level: zerolog.InfoLevel,
+		msg:   defaultBackendMessage,
+		key:   defaultBackendKey,
+	}
+	for _, fn := range opts {
+		fn(w)
+	}
+	return w
+}
+
+// Write implements io.Writer.
+func (w *ZerologLogWriter) Write(p []byte) (int, error) {
+	eachLog(p, func(line []byte, isJSON bool) {
+		if !isJSON {
+			w.l.WithLevel(w.level).Msg(string(line))
+			return
+		}
+		w.l.WithLevel(w.level).RawJSON(w.key, line).Msg(w.msg)
+	})
+	return len(p), nil
+}
@@ -0,0 +1,26 @@
+package writer
+
+import "github.com/rs/zerolog"
+
+type zerologOption func(w *ZerologLogWriter)
+
+// ZerologLevel specifies the level of logs. The default is zerolog.InfoLevel.
+func ZerologLevel(l zerolog.Level) zerologOption {
+	return func(w *ZerologLogWriter) {
+		w.level = l
+	}
+}
+
+// ZerologMessage specifies the message of logs. The default is "access log".
+func ZerologMessage(msg string) zerologOption {
+	return func(w *ZerologLogWriter) {
+		w.msg = msg
+	}
+}
+
+// ZerologKey specifies the key of the field the logs are embedded in. The default is "access_log".
+func ZerologKey(key string) zerologOption {
+	return func(w *ZerologLogWriter) {
+		w.key = key
+	}
+}